@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var activeCodeFlag string
+var activeAtFlag string
+
+var ActiveCmd = &cobra.Command{
+	Use:   "active",
+	Short: "Affiche la destination active d'un lien selon ses règles horaires.",
+	Long: `Cette commande évalue les règles horaires d'un lien à l'instant présent (ou à l'instant
+donné par --at) et affiche la destination vers laquelle il redirige, la règle appliquée et
+la date du prochain changement. Les règles qui dépendent du visiteur (ciblage par appareil,
+langue, variantes) ne sont pas évaluées.
+
+Exemple:
+  url-shortener active --code="support"
+  url-shortener active --code="support" --at="2030-01-06T20:30:00+01:00"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		at := time.Now()
+		if activeAtFlag != "" {
+			parsed, err := time.Parse(time.RFC3339, activeAtFlag)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Instant invalide (format RFC 3339 attendu): %v\n", err)
+				os.Exit(1)
+			}
+			at = parsed
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		linkService := newLinkService(db)
+
+		link, route, err := linkService.ActiveDestination(activeCodeFlag, at)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				fmt.Fprintf(os.Stderr, "Aucun lien trouvé pour le code court: %s\n", activeCodeFlag)
+			} else {
+				fmt.Fprintf(os.Stderr, "Erreur lors de l'évaluation des règles horaires: %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("Code: %s\n", link.Shortcode)
+		fmt.Printf("Instant: %s\n", at.Format(time.RFC3339))
+		fmt.Printf("Destination active: %s\n", route.Destination)
+		if route.Rule != nil {
+			fmt.Printf("Règle appliquée: %d (%s)\n", route.RuleIndex, windowLabel(route.Rule.AvailabilityWindow))
+		} else if len(link.TimeRules) > 0 {
+			fmt.Printf("Règle appliquée: aucune, URL longue\n")
+		} else {
+			fmt.Printf("Le lien n'a pas de règle horaire.\n")
+		}
+		if route.NextChange != nil {
+			fmt.Printf("Prochain changement: %s\n", route.NextChange.In(at.Location()).Format(time.RFC3339))
+		}
+		if state := services.LinkState(link, at); state != services.LinkStateActive {
+			fmt.Printf("Attention: le lien n'est pas servi à cet instant (état: %s).\n", state)
+		}
+	},
+}
+
+func init() {
+	ActiveCmd.Flags().StringVar(&activeCodeFlag, "code", "", "Code court du lien à évaluer")
+	ActiveCmd.Flags().StringVar(&activeAtFlag, "at", "", "Instant d'évaluation au format RFC 3339 (maintenant par défaut)")
+	ActiveCmd.MarkFlagRequired("code")
+
+	cmd2.RootCmd.AddCommand(ActiveCmd)
+}
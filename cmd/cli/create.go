@@ -3,20 +3,42 @@ package cli
 import (
 	"fmt"
 	"log"
-	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 
-	"github.com/axellelanca/urlshortener/cmd"
-	"github.com/axellelanca/urlshortener/internal/config"
-	"github.com/axellelanca/urlshortener/internal/repository"
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 var longURLFlag string
+var aliasFlag string
+var expiresAtFlag string
+var maxClicksFlag int
+var reuseFlag bool
+var redirectStatusFlag int
+var headerFlags []string
+var forwardQueryFlag bool
+var queryConflictFlag string
+var forwardPathFlag bool
+var passwordFlag string
+var onceFlag bool
+var activateAtFlag string
+var windowDaysFlag []string
+var windowStartFlag string
+var windowEndFlag string
+var windowTZFlag string
+var fallbackURLFlag string
+var variantFlags []string
+var stickyFlag bool
+var targetFlags []string
+var langFlags []string
+var timeRuleFlags []string
+var ruleFlags []string
 
 var CreateCmd = &cobra.Command{
 	Use:   "create",
@@ -24,58 +46,405 @@ var CreateCmd = &cobra.Command{
 	Long: `Cette commande raccourcit une URL longue fournie et affiche le code court généré.
 
 Exemple:
-  url-shortener create --url="https://www.google.com/search?q=go+lang"`,
+  url-shortener create --url="https://www.google.com/search?q=go+lang"
+  url-shortener create --url="https://go.dev" --alias="golang"
+  url-shortener create --url="https://go.dev" --expires-at="2030-01-01T00:00:00Z" --max-clicks=100
+  url-shortener create --url="https://go.dev" --reuse-existing
+  url-shortener create --url="https://go.dev" --status=301 --header="Cache-Control: public, max-age=604800"
+  url-shortener create --url="https://go.dev/doc" --forward-path --forward-query --query-conflict=request
+  url-shortener create --url="https://intranet.example.com/rapport.pdf" --password="s3cret"
+  url-shortener create --url="https://example.com/invitation" --once
+  url-shortener create --url="https://example.com/lancement" --activate-at="2030-03-01T09:00:00+01:00" --fallback-url="https://example.com/bientot"
+  url-shortener create --url="https://example.com/support" --window-days=mon,tue,wed,thu,fri --window-start=09:00 --window-end=18:00 --window-tz=Europe/Paris
+  url-shortener create --variant="a:70:https://example.com/v1" --variant="b:30:https://example.com/v2" --sticky
+  url-shortener create --url="https://example.com/app" --target="os=ios->https://apps.apple.com/app/id123" --target="os=android,device=mobile->https://play.google.com/store/apps/details?id=com.example"
+  url-shortener create --url="https://docs.example.com/en/" --lang="fr=https://docs.example.com/fr/" --lang="fr-CA=https://docs.example.com/fr-ca/"
+  url-shortener create --url="https://support.example.com/ticket" --time-rule="mon,tue,wed,thu,fri 09:00-18:00 Europe/Paris->https://support.example.com/chat"
+  url-shortener create --url="https://example.com" --rule='header("X-Team") == "beta" || ip_in(ip, "10.0.0.0/8")->https://beta.example.com'`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if longURLFlag == "" {
-			log.Fatal("FATAL: Le flag --url est requis.")
+		if longURLFlag == "" && len(variantFlags) == 0 {
+			log.Fatal("FATAL: Le flag --url (ou des flags --variant) est requis.")
 		}
 
-		if _, err := url.ParseRequestURI(longURLFlag); err != nil {
-			log.Printf("FATAL: URL invalide: %v", err)
-			os.Exit(1)
+		opts := services.CreateLinkOptions{Alias: aliasFlag, Once: onceFlag}
+		if expiresAtFlag != "" {
+			expiresAt, err := time.Parse(time.RFC3339, expiresAtFlag)
+			if err != nil {
+				log.Printf("FATAL: Date d'expiration invalide (format RFC 3339 attendu): %v", err)
+				os.Exit(1)
+			}
+			opts.ExpiresAt = &expiresAt
 		}
-
-		cfg, err := config.LoadConfig()
-		if err != nil {
-			log.Fatalf("FATAL: Échec du chargement de la configuration: %v", err)
+		if cmd.Flags().Changed("max-clicks") {
+			opts.MaxClicks = &maxClicksFlag
 		}
-
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
-		if err != nil {
-			log.Fatalf("FATAL: Échec de la connexion à la base de données: %v", err)
+		if activateAtFlag != "" {
+			activateAt, err := time.Parse(time.RFC3339, activateAtFlag)
+			if err != nil {
+				log.Printf("FATAL: Date d'activation invalide (format RFC 3339 attendu): %v", err)
+				os.Exit(1)
+			}
+			opts.ActivateAt = &activateAt
 		}
-
-		sqlDB, err := db.DB()
+		opts.Availability = availabilityFromFlags(cmd)
+		opts.FallbackURL = fallbackURLFlag
+		opts.RedirectStatus = redirectStatusFlag
+		headers, err := parseHeaderFlags(headerFlags)
 		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+			log.Printf("FATAL: %v", err)
+			os.Exit(1)
+		}
+		opts.Headers = headers
+		opts.ForwardQuery = forwardQueryFlag
+		opts.QueryConflict = queryConflictFlag
+		opts.ForwardPath = forwardPathFlag
+		opts.Password = passwordFlag
+		if opts.Variants, err = parseVariantFlags(variantFlags); err != nil {
+			log.Printf("FATAL: %v", err)
+			os.Exit(1)
+		}
+		opts.StickyVariants = stickyFlag
+		if opts.Targeting, err = parseTargetFlags(targetFlags); err != nil {
+			log.Printf("FATAL: %v", err)
+			os.Exit(1)
+		}
+		if opts.Localized, err = parseLangFlags(langFlags); err != nil {
+			log.Printf("FATAL: %v", err)
+			os.Exit(1)
+		}
+		if opts.TimeRules, err = parseTimeRuleFlags(timeRuleFlags); err != nil {
+			log.Printf("FATAL: %v", err)
+			os.Exit(1)
+		}
+		if opts.RedirectRules, err = parseRuleFlags(ruleFlags); err != nil {
+			log.Printf("FATAL: %v", err)
+			os.Exit(1)
 		}
 
-		defer func() {
-			if err := sqlDB.Close(); err != nil {
-				log.Printf("Erreur lors de la fermeture de la base de données: %v", err)
-			}
-		}()
+		cfg := cmd2.Cfg
+		db, closeDB := openDatabase()
+		defer closeDB()
 
-		linkRepo := repository.NewLinkRepository(db)
-		linkService := services.NewLinkService(linkRepo)
+		linkService := newLinkService(db)
 
-		link, err := linkService.CreateLink(longURLFlag)
+		var link *models.Link
+		created := true
+		if reuseFlag {
+			link, created, err = linkService.FindOrCreateLink(longURLFlag, opts)
+		} else {
+			link, err = linkService.CreateLink(longURLFlag, opts)
+		}
 		if err != nil {
 			log.Printf("FATAL: Échec de la création du lien: %v", err)
 			os.Exit(1)
 		}
 
 		fullShortURL := fmt.Sprintf("%s/%s", cfg.Server.BaseURL, link.Shortcode)
-		fmt.Printf("URL courte créée avec succès:\n")
+		if created {
+			fmt.Printf("URL courte créée avec succès:\n")
+		} else {
+			fmt.Printf("URL courte existante réutilisée:\n")
+		}
 		fmt.Printf("Code: %s\n", link.Shortcode)
+		fmt.Printf("URL longue: %s\n", link.LongURL)
 		fmt.Printf("URL complète: %s\n", fullShortURL)
+		if link.ExpiresAt != nil {
+			fmt.Printf("Expire le: %s\n", link.ExpiresAt.Format(time.RFC3339))
+		}
+		if link.MaxClicks != nil {
+			fmt.Printf("Nombre maximal de clics: %d\n", *link.MaxClicks)
+		}
+		if link.SingleUse {
+			fmt.Printf("Usage unique: le lien expire après la première redirection\n")
+		}
+		printSchedule(link)
+		printVariants(link)
+		printTargeting(link)
+		printLocalized(link)
+		printTimeRules(link)
+		printRedirectRules(link)
+		if link.RedirectStatus != 0 {
+			fmt.Printf("Statut de redirection: %d\n", link.RedirectStatus)
+		}
+		for name, value := range link.ResponseHeaders {
+			fmt.Printf("En-tête: %s: %s\n", name, value)
+		}
+		if link.ForwardQuery {
+			fmt.Printf("Transfert de la query string: oui (conflits: %s)\n", queryConflictLabel(link.QueryConflict))
+		}
+		if link.ForwardPath {
+			fmt.Printf("Transfert du chemin: oui\n")
+		}
+		if services.IsPasswordProtected(link) {
+			fmt.Printf("Protégé par mot de passe: oui\n")
+		}
 	},
 }
 
+// availabilityFromFlags construit la plage de disponibilité demandée par les flags --window-*,
+// ou renvoie nil si aucun n'est utilisé.
+func availabilityFromFlags(cmd *cobra.Command) *models.AvailabilityWindow {
+	if !cmd.Flags().Changed("window-start") && !cmd.Flags().Changed("window-end") &&
+		!cmd.Flags().Changed("window-days") && !cmd.Flags().Changed("window-tz") {
+		return nil
+	}
+	return &models.AvailabilityWindow{
+		Days:     windowDaysFlag,
+		Start:    windowStartFlag,
+		End:      windowEndFlag,
+		Timezone: windowTZFlag,
+	}
+}
+
+// printSchedule affiche la programmation d'un lien et son état courant.
+func printSchedule(link *models.Link) {
+	if link.ActivateAt != nil {
+		fmt.Printf("Activé le: %s\n", link.ActivateAt.Format(time.RFC3339))
+	}
+	if w := link.Availability; w != nil {
+		fmt.Printf("Disponibilité: %s\n", windowLabel(*w))
+	}
+	if link.FallbackURL != "" {
+		fmt.Printf("URL de repli: %s\n", link.FallbackURL)
+	}
+	fmt.Printf("État: %s\n", services.LinkState(link, time.Now()))
+}
+
+// queryConflictLabel renvoie la politique de conflit affichée, en explicitant la valeur par défaut.
+func queryConflictLabel(policy string) string {
+	if policy == "" {
+		return services.QueryConflictDestination
+	}
+	return policy
+}
+
+// parseVariantFlags convertit des flags --variant au format "nom:poids:url" en variantes de lien.
+func parseVariantFlags(flags []string) ([]models.LinkVariant, error) {
+	if len(flags) == 0 {
+		return nil, nil
+	}
+	variants := make([]models.LinkVariant, 0, len(flags))
+	for _, flag := range flags {
+		parts := strings.SplitN(flag, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("variante invalide '%s', format attendu \"nom:poids:url\"", flag)
+		}
+		weight, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("poids invalide pour la variante '%s': %s", parts[0], parts[1])
+		}
+		variants = append(variants, models.LinkVariant{Name: parts[0], Weight: weight, URL: parts[2]})
+	}
+	return variants, nil
+}
+
+// printVariants affiche les destinations d'un lien en test A/B.
+func printVariants(link *models.Link) {
+	if len(link.Variants) == 0 {
+		return
+	}
+	total := 0
+	for _, v := range link.Variants {
+		total += v.Weight
+	}
+	for _, v := range link.Variants {
+		fmt.Printf("Variante %s (poids %d, %.0f%%): %s\n", v.Name, v.Weight, float64(v.Weight)*100/float64(total), v.URL)
+	}
+	if link.StickyVariants {
+		fmt.Printf("Variante persistante par visiteur: oui\n")
+	}
+}
+
+// parseTargetFlags convertit des flags --target au format "critère=valeur,...->url" en règles de ciblage.
+// Les critères acceptés sont os, device et browser.
+func parseTargetFlags(flags []string) ([]models.TargetingRule, error) {
+	if len(flags) == 0 {
+		return nil, nil
+	}
+	rules := make([]models.TargetingRule, 0, len(flags))
+	for _, flag := range flags {
+		criteria, url, found := strings.Cut(flag, "->")
+		if !found {
+			return nil, fmt.Errorf("règle de ciblage invalide '%s', format attendu \"os=ios,device=mobile->url\"", flag)
+		}
+		rule := models.TargetingRule{URL: strings.TrimSpace(url)}
+		for _, criterion := range strings.Split(criteria, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(criterion), "=")
+			switch name {
+			case "os":
+				rule.OS = value
+			case "device":
+				rule.Device = value
+			case "browser":
+				rule.Browser = value
+			default:
+				return nil, fmt.Errorf("critère de ciblage inconnu '%s' (os, device ou browser)", name)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// printTargeting affiche les règles de ciblage d'un lien, dans leur ordre d'évaluation.
+func printTargeting(link *models.Link) {
+	for i, rule := range link.TargetingRules {
+		var criteria []string
+		if rule.OS != "" {
+			criteria = append(criteria, "os="+rule.OS)
+		}
+		if rule.Device != "" {
+			criteria = append(criteria, "device="+rule.Device)
+		}
+		if rule.Browser != "" {
+			criteria = append(criteria, "browser="+rule.Browser)
+		}
+		fmt.Printf("Ciblage %d: %s -> %s\n", i+1, strings.Join(criteria, ","), rule.URL)
+	}
+}
+
+// parseLangFlags convertit des flags --lang au format "langue=url" en destinations par langue.
+func parseLangFlags(flags []string) (map[string]string, error) {
+	if len(flags) == 0 {
+		return nil, nil
+	}
+	localized := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		tag, url, found := strings.Cut(flag, "=")
+		if !found || strings.TrimSpace(tag) == "" {
+			return nil, fmt.Errorf("destination par langue invalide '%s', format attendu \"langue=url\"", flag)
+		}
+		localized[strings.TrimSpace(tag)] = strings.TrimSpace(url)
+	}
+	return localized, nil
+}
+
+// printLocalized affiche les destinations par langue d'un lien.
+func printLocalized(link *models.Link) {
+	for _, tag := range services.LinkLanguages(link) {
+		fmt.Printf("Langue %s: %s\n", tag, link.Localized[tag])
+	}
+}
+
+// parseTimeRuleFlags convertit des flags --time-rule au format "jours HH:MM-HH:MM [fuseau]->url"
+// en règles horaires. Les jours sont séparés par des virgules, "*" désigne tous les jours.
+func parseTimeRuleFlags(flags []string) ([]models.TimeRule, error) {
+	if len(flags) == 0 {
+		return nil, nil
+	}
+	rules := make([]models.TimeRule, 0, len(flags))
+	for _, flag := range flags {
+		window, url, found := strings.Cut(flag, "->")
+		fields := strings.Fields(window)
+		if !found || len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("règle horaire invalide '%s', format attendu \"mon,tue 09:00-18:00 Europe/Paris->url\"", flag)
+		}
+		start, end, found := strings.Cut(fields[1], "-")
+		if !found {
+			return nil, fmt.Errorf("heures invalides '%s' dans la règle horaire, format attendu HH:MM-HH:MM", fields[1])
+		}
+
+		rule := models.TimeRule{URL: strings.TrimSpace(url)}
+		rule.Start, rule.End = start, end
+		if fields[0] != "*" {
+			rule.Days = strings.Split(fields[0], ",")
+		}
+		if len(fields) == 3 {
+			rule.Timezone = fields[2]
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// printTimeRules affiche les règles horaires d'un lien, dans leur ordre d'évaluation.
+func printTimeRules(link *models.Link) {
+	for i, rule := range link.TimeRules {
+		fmt.Printf("Règle horaire %d: %s -> %s\n", i+1, windowLabel(rule.AvailabilityWindow), rule.URL)
+	}
+}
+
+// windowLabel décrit une plage horaire récurrente pour l'affichage.
+func windowLabel(w models.AvailabilityWindow) string {
+	days := "tous les jours"
+	if len(w.Days) > 0 {
+		days = strings.Join(w.Days, ",")
+	}
+	tz := w.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+	return fmt.Sprintf("%s de %s à %s (%s)", days, w.Start, w.End, tz)
+}
+
+// parseRuleFlags convertit des flags --rule au format "condition->url" en règles de redirection.
+// La dernière flèche sépare la condition de l'URL : la condition peut en contenir dans ses chaînes.
+func parseRuleFlags(flags []string) ([]models.RedirectRule, error) {
+	if len(flags) == 0 {
+		return nil, nil
+	}
+	list := make([]models.RedirectRule, 0, len(flags))
+	for _, flag := range flags {
+		i := strings.LastIndex(flag, "->")
+		if i < 0 {
+			return nil, fmt.Errorf("règle de redirection invalide '%s', format attendu \"condition->url\"", flag)
+		}
+		list = append(list, models.RedirectRule{Condition: strings.TrimSpace(flag[:i]), URL: strings.TrimSpace(flag[i+2:])})
+	}
+	return list, nil
+}
+
+// printRedirectRules affiche les règles de redirection d'un lien, dans leur ordre d'évaluation.
+func printRedirectRules(link *models.Link) {
+	for i, rule := range link.RedirectRules {
+		fmt.Printf("Règle %d: si %s -> %s\n", i+1, rule.Condition, rule.URL)
+	}
+}
+
+// parseHeaderFlags convertit des flags --header au format "Nom: valeur" en map d'en-têtes.
+func parseHeaderFlags(flags []string) (map[string]string, error) {
+	if len(flags) == 0 {
+		return nil, nil
+	}
+	headers := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		name, value, ok := strings.Cut(flag, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("en-tête invalide '%s' (format attendu \"Nom: valeur\")", flag)
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
 func init() {
 	CreateCmd.Flags().StringVar(&longURLFlag, "url", "", "URL longue à raccourcir")
+	CreateCmd.Flags().StringVar(&aliasFlag, "alias", "", "Alias personnalisé à utiliser comme code court (optionnel)")
+	CreateCmd.Flags().StringVar(&expiresAtFlag, "expires-at", "", "Date d'expiration du lien au format RFC 3339 (optionnel)")
+	CreateCmd.Flags().BoolVar(&onceFlag, "once", false, "Lien à usage unique, consommé par la première redirection")
+	CreateCmd.Flags().IntVar(&maxClicksFlag, "max-clicks", 0, "Nombre maximal de redirections avant expiration (optionnel)")
+	CreateCmd.Flags().IntVar(&redirectStatusFlag, "status", 0, "Statut de redirection du lien : 301, 302, 307 ou 308 (optionnel, statut global par défaut)")
+	CreateCmd.Flags().StringArrayVar(&headerFlags, "header", nil, "En-tête ajouté à la redirection, au format \"Nom: valeur\" (répétable)")
+	CreateCmd.Flags().BoolVar(&forwardQueryFlag, "forward-query", false, "Ajouter les paramètres de la requête à l'URL longue")
+	CreateCmd.Flags().StringVar(&queryConflictFlag, "query-conflict", "", "Paramètre déjà présent dans l'URL longue : destination (par défaut), request ou append")
+	CreateCmd.Flags().BoolVar(&forwardPathFlag, "forward-path", false, "Ajouter le chemin qui suit le code court à l'URL longue")
+	CreateCmd.Flags().StringVar(&passwordFlag, "password", "", "Mot de passe demandé aux visiteurs avant la redirection (optionnel)")
+	CreateCmd.Flags().StringVar(&activateAtFlag, "activate-at", "", "Date d'activation du lien au format RFC 3339 (optionnel)")
+	CreateCmd.Flags().StringSliceVar(&windowDaysFlag, "window-days", nil, "Jours de disponibilité (mon,tue,...), tous les jours si absent")
+	CreateCmd.Flags().StringVar(&windowStartFlag, "window-start", "00:00", "Début de la plage de disponibilité quotidienne (HH:MM)")
+	CreateCmd.Flags().StringVar(&windowEndFlag, "window-end", "00:00", "Fin de la plage de disponibilité quotidienne (HH:MM, exclue)")
+	CreateCmd.Flags().StringVar(&windowTZFlag, "window-tz", "", "Fuseau horaire de la plage de disponibilité (ex: Europe/Paris), UTC par défaut")
+	CreateCmd.Flags().StringVar(&fallbackURLFlag, "fallback-url", "", "URL servie tant que le lien n'est pas disponible (optionnel)")
+	CreateCmd.Flags().StringArrayVar(&variantFlags, "variant", nil, "Destination d'un test A/B au format \"nom:poids:url\" (répétable, remplace --url)")
+	CreateCmd.Flags().BoolVar(&stickyFlag, "sticky", false, "Servir toujours la même variante à un visiteur (cookie)")
+	CreateCmd.Flags().StringArrayVar(&targetFlags, "target", nil, "Règle de ciblage par appareil au format \"os=ios,device=mobile->url\" (répétable, évaluée dans l'ordre)")
+	CreateCmd.Flags().StringArrayVar(&langFlags, "lang", nil, "Destination pour une langue au format \"fr-CA=url\" (répétable), négociée avec Accept-Language")
+	CreateCmd.Flags().StringArrayVar(&timeRuleFlags, "time-rule", nil, "Destination pendant une plage horaire au format \"mon,tue 09:00-18:00 Europe/Paris->url\" (répétable, \"*\" pour tous les jours)")
+	CreateCmd.Flags().StringArrayVar(&ruleFlags, "rule", nil, "Règle de redirection au format \"condition->url\" (répétable, évaluée dans l'ordre avant les autres règles)")
+	CreateCmd.Flags().BoolVar(&reuseFlag, "reuse-existing", false, "Réutiliser un lien existant vers la même URL au lieu d'en créer un nouveau")
 
-	CreateCmd.MarkFlagRequired("url")
+	CreateCmd.MarkFlagsOneRequired("url", "variant")
+	CreateCmd.MarkFlagsMutuallyExclusive("url", "variant")
 
-	cmd.RootCmd.AddCommand(CreateCmd)
+	cmd2.RootCmd.AddCommand(CreateCmd)
 }
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"log"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"gorm.io/gorm"
+)
+
+// openDatabase ouvre la base de données configurée pour une commande CLI.
+// Elle termine le programme en cas d'échec et renvoie une fonction à différer pour fermer la connexion.
+func openDatabase() (*gorm.DB, func()) {
+	cfg := cmd2.Cfg
+	if cfg == nil {
+		log.Fatalf("FATAL: Impossible de charger la configuration globale.")
+	}
+
+	db, err := database.Open(cfg.Database.Name)
+	if err != nil {
+		log.Fatalf("FATAL: Impossible d'ouvrir la base de données: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+	}
+
+	return db, func() {
+		if err := sqlDB.Close(); err != nil {
+			log.Printf("Erreur lors de la fermeture de la base de données: %v", err)
+		}
+	}
+}
+
+// newLinkService construit le LinkService des commandes CLI à partir de la configuration globale,
+// afin que les liens créés en ligne de commande suivent la même stratégie de génération que le serveur.
+func newLinkService(db *gorm.DB) *services.LinkService {
+	policy, err := services.LoadDestinationPolicyFromConfig(cmd2.Cfg)
+	if err != nil {
+		log.Fatalf("FATAL: Politique de domaines invalide: %v", err)
+	}
+
+	linkRepo := repository.NewLinkRepository(db)
+	linkService, err := services.NewLinkServiceFromConfig(linkRepo, cmd2.Cfg, services.WithDestinationPolicy(policy))
+	if err != nil {
+		log.Fatalf("FATAL: Configuration du service de liens invalide: %v", err)
+	}
+	return linkService
+}
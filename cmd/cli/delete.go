@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deleteCodeFlag  string
+	deleteForceFlag bool
+)
+
+var DeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Supprime un lien court et ses statistiques.",
+	Long: `Cette commande supprime définitivement un lien court ainsi que tous les clics enregistrés.
+Une confirmation est demandée, sauf si le flag --force est fourni.
+
+Exemple:
+  url-shortener delete --code="xyz123" --force`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !deleteForceFlag {
+			fmt.Printf("Supprimer définitivement le lien '%s' et ses clics ? [o/N] ", deleteCodeFlag)
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			if answer != "o" && answer != "oui" && answer != "y" && answer != "yes" {
+				fmt.Println("Suppression annulée.")
+				return
+			}
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		linkService := newLinkService(db)
+
+		if err := linkService.DeleteLink(deleteCodeFlag); err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				fmt.Fprintf(os.Stderr, "Aucun lien trouvé pour le code court: %s\n", deleteCodeFlag)
+			} else {
+				fmt.Fprintf(os.Stderr, "Erreur lors de la suppression du lien: %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("Lien '%s' supprimé avec succès.\n", deleteCodeFlag)
+	},
+}
+
+func init() {
+	DeleteCmd.Flags().StringVar(&deleteCodeFlag, "code", "", "Code court du lien à supprimer")
+	DeleteCmd.Flags().BoolVar(&deleteForceFlag, "force", false, "Supprimer sans demander de confirmation")
+
+	DeleteCmd.MarkFlagRequired("code")
+
+	cmd2.RootCmd.AddCommand(DeleteCmd)
+}
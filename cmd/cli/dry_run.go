@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dryRunCodeFlag    string
+	dryRunHeaderFlags []string
+	dryRunQueryFlag   string
+	dryRunCookieFlags []string
+	dryRunIPFlag      string
+	dryRunUAFlag      string
+	dryRunPathFlag    string
+	dryRunAtFlag      string
+)
+
+var DryRunCmd = &cobra.Command{
+	Use:   "dry-run",
+	Short: "Essaie les règles d'un lien sur une visite fictive.",
+	Long: `Cette commande évalue les règles d'un lien (règles de redirection, ciblage, règles horaires,
+langues, variantes) sur une visite fictive décrite par les flags, sans redirection ni
+enregistrement de clic. Elle affiche la destination choisie et le résultat de chaque règle
+de redirection.
+
+Exemple:
+  url-shortener dry-run --code="xyz123" --header="X-Team: beta"
+  url-shortener dry-run --code="xyz123" --ip=10.1.2.3 --ua="Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)"
+  url-shortener dry-run --code="xyz123" --query="utm_source=newsletter" --cookie="plan=pro" --at="2030-01-07T10:00:00+01:00"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		at := time.Now()
+		if dryRunAtFlag != "" {
+			parsed, err := time.Parse(time.RFC3339, dryRunAtFlag)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Instant invalide (format RFC 3339 attendu): %v\n", err)
+				os.Exit(1)
+			}
+			at = parsed
+		}
+
+		headers, err := parseHeaderFlags(dryRunHeaderFlags)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		header := make(http.Header, len(headers))
+		for name, value := range headers {
+			header.Set(name, value)
+		}
+		if dryRunUAFlag != "" {
+			header.Set("User-Agent", dryRunUAFlag)
+		}
+		query, err := url.ParseQuery(dryRunQueryFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Query string invalide: %v\n", err)
+			os.Exit(1)
+		}
+		cookies := make(map[string]string, len(dryRunCookieFlags))
+		for _, flag := range dryRunCookieFlags {
+			name, value, found := strings.Cut(flag, "=")
+			if !found {
+				fmt.Fprintf(os.Stderr, "Cookie invalide '%s', format attendu \"nom=valeur\"\n", flag)
+				os.Exit(1)
+			}
+			cookies[name] = value
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		linkService := newLinkService(db)
+
+		result, err := linkService.DryRunRules(dryRunCodeFlag, services.Visit{
+			Path:           dryRunPathFlag,
+			Query:          query,
+			UserAgent:      header.Get("User-Agent"),
+			AcceptLanguage: header.Get("Accept-Language"),
+			Header:         header,
+			Cookies:        cookies,
+			ClientIP:       dryRunIPFlag,
+		}, at)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				fmt.Fprintf(os.Stderr, "Aucun lien trouvé pour le code court: %s\n", dryRunCodeFlag)
+			} else {
+				fmt.Fprintf(os.Stderr, "Erreur lors de l'essai des règles: %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		for _, trace := range result.Rules {
+			status := "non"
+			switch {
+			case trace.Error != "":
+				status = "invalide (" + trace.Error + ")"
+			case trace.Matched:
+				status = "oui"
+			}
+			fmt.Printf("Règle %d: %s -> %s : %s\n", trace.Index, trace.Condition, trace.URL, status)
+		}
+		fmt.Printf("Destination: %s\n", result.Destination)
+		origin := result.Source
+		switch {
+		case result.Rule > 0:
+			origin = fmt.Sprintf("%s %d", result.Source, result.Rule)
+		case result.Variant != "":
+			origin = fmt.Sprintf("%s %s", result.Source, result.Variant)
+		case result.Language != "":
+			origin = fmt.Sprintf("%s %s", result.Source, result.Language)
+		}
+		fmt.Printf("Origine: %s\n", origin)
+		if result.State != services.LinkStateActive {
+			fmt.Printf("Attention: le lien n'est pas servi à cet instant (état: %s).\n", result.State)
+		}
+	},
+}
+
+func init() {
+	DryRunCmd.Flags().StringVar(&dryRunCodeFlag, "code", "", "Code court du lien à essayer")
+	DryRunCmd.Flags().StringArrayVar(&dryRunHeaderFlags, "header", nil, "En-tête de la visite au format \"Nom: valeur\" (répétable)")
+	DryRunCmd.Flags().StringVar(&dryRunQueryFlag, "query", "", "Query string de la visite (ex: \"utm_source=mail&ref=x\")")
+	DryRunCmd.Flags().StringArrayVar(&dryRunCookieFlags, "cookie", nil, "Cookie de la visite au format \"nom=valeur\" (répétable)")
+	DryRunCmd.Flags().StringVar(&dryRunIPFlag, "ip", "", "Adresse IP du visiteur")
+	DryRunCmd.Flags().StringVar(&dryRunUAFlag, "ua", "", "User-Agent du visiteur")
+	DryRunCmd.Flags().StringVar(&dryRunPathFlag, "path", "", "Chemin qui suit le code court (ex: \"/docs\")")
+	DryRunCmd.Flags().StringVar(&dryRunAtFlag, "at", "", "Instant de la visite au format RFC 3339 (maintenant par défaut)")
+	DryRunCmd.MarkFlagRequired("code")
+
+	cmd2.RootCmd.AddCommand(DryRunCmd)
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// inspectRecentClicks est le nombre de clics récents affichés par la commande 'inspect'.
+const inspectRecentClicks = 10
+
+var inspectCodeFlag string
+
+var InspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Affiche le détail complet d'un lien court et ses derniers clics.",
+	Long: `Cette commande affiche tous les champs d'un lien court ainsi que les clics
+les plus récents enregistrés pour ce lien.
+
+Exemple:
+  url-shortener inspect --code="xyz123"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		clickRepo := repository.NewClickRepository(db)
+		linkService := newLinkService(db)
+		clickService := services.NewClickService(clickRepo)
+
+		link, err := linkService.GetLinkByShortCode(inspectCodeFlag)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				fmt.Fprintf(os.Stderr, "Aucun lien trouvé pour le code court: %s\n", inspectCodeFlag)
+			} else {
+				fmt.Fprintf(os.Stderr, "Erreur lors de la récupération du lien: %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		clicks, err := clickService.GetRecentClicks(link.ID, inspectRecentClicks)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors de la récupération des clics: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("ID: %d\n", link.ID)
+		fmt.Printf("Code: %s\n", link.Shortcode)
+		fmt.Printf("URL complète: %s/%s\n", cmd2.Cfg.Server.BaseURL, link.Shortcode)
+		fmt.Printf("URL longue: %s\n", link.LongURL)
+		fmt.Printf("Domaine: %s\n", link.Domain)
+		fmt.Printf("Créé le: %s\n", link.CreatedAt.Format(time.RFC3339))
+		fmt.Printf("Redirections servies: %d\n", link.UsedClicks)
+		if link.ExpiresAt != nil {
+			fmt.Printf("Expire le: %s\n", link.ExpiresAt.Format(time.RFC3339))
+		}
+		if link.MaxClicks != nil {
+			fmt.Printf("Nombre maximal de clics: %d\n", *link.MaxClicks)
+		}
+		if link.SingleUse {
+			fmt.Printf("Usage unique: oui\n")
+		}
+		if link.ExpiredAt != nil {
+			fmt.Printf("Marqué expiré le: %s\n", link.ExpiredAt.Format(time.RFC3339))
+		}
+
+		printMetadata(link)
+		printVariants(link)
+		printTargeting(link)
+		printLocalized(link)
+		printTimeRules(link)
+		printRedirectRules(link)
+
+		// Redirection effectivement servie, réglages globaux compris.
+		redirect := linkService.RedirectFor(link)
+		fmt.Printf("Statut de redirection: %d\n", redirect.Status)
+		names := make([]string, 0, len(redirect.Headers))
+		for name := range redirect.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  %s: %s\n", name, redirect.Headers.Get(name))
+		}
+
+		fmt.Printf("\nDerniers clics (%d):\n", len(clicks))
+		for _, click := range clicks {
+			// Variante servie et langue négociée, lorsque le lien en a.
+			var served []string
+			if click.Variant != "" {
+				served = append(served, click.Variant)
+			}
+			if click.Language != "" {
+				served = append(served, click.Language)
+			}
+			if len(served) > 0 {
+				fmt.Printf("  %s  %-15s  [%s]  %s\n", click.Timestamp.Format(time.RFC3339), click.IPAddress, strings.Join(served, " "), click.UserAgent)
+				continue
+			}
+			fmt.Printf("  %s  %-15s  %s\n", click.Timestamp.Format(time.RFC3339), click.IPAddress, click.UserAgent)
+		}
+	},
+}
+
+// printMetadata affiche les informations récupérées sur la page de destination d'un lien.
+func printMetadata(link *models.Link) {
+	m := link.Metadata
+	if m == nil {
+		fmt.Printf("Informations de la destination: pas encore récupérées\n")
+		return
+	}
+	fmt.Printf("Informations de la destination (récupérées le %s):\n", m.FetchedAt.Format(time.RFC3339))
+	if m.Error != "" {
+		fmt.Printf("  Dernier échec: %s\n", m.Error)
+	}
+	if m.Title != "" {
+		fmt.Printf("  Titre: %s\n", m.Title)
+	}
+	if m.Description != "" {
+		fmt.Printf("  Description: %s\n", m.Description)
+	}
+	if m.Image != "" {
+		fmt.Printf("  Image: %s\n", m.Image)
+	}
+	if m.Favicon != "" {
+		fmt.Printf("  Icône: %s\n", m.Favicon)
+	}
+}
+
+func init() {
+	InspectCmd.Flags().StringVar(&inspectCodeFlag, "code", "", "Code court du lien à inspecter")
+
+	InspectCmd.MarkFlagRequired("code")
+
+	cmd2.RootCmd.AddCommand(InspectCmd)
+}
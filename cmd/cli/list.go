@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listLimitFlag  int
+	listOffsetFlag int
+	listSearchFlag string
+)
+
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les liens courts enregistrés.",
+	Long: `Cette commande affiche une page de liens courts, du plus récent au plus ancien.
+Le flag --search filtre les liens dont le code court ou l'URL longue contient le texte donné.
+
+Exemple:
+  url-shortener list --limit=10 --offset=20 --search="example.com"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		linkService := newLinkService(db)
+
+		page, err := linkService.ListLinks(services.ListLinksQuery{
+			Limit:  listLimitFlag,
+			Offset: listOffsetFlag,
+			Search: listSearchFlag,
+			Sort:   "-created_at",
+		})
+		if err != nil {
+			log.Printf("FATAL: Échec de la récupération des liens: %v", err)
+			os.Exit(1)
+		}
+
+		if len(page.Links) == 0 {
+			fmt.Println("Aucun lien trouvé.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CODE\tURL LONGUE\tCRÉÉ LE\tCLICS\tÉTAT")
+		now := time.Now()
+		for i := range page.Links {
+			link := &page.Links[i]
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", link.Shortcode, link.LongURL, link.CreatedAt.Format("2006-01-02 15:04"),
+				link.UsedClicks, services.LinkState(link, now))
+		}
+		w.Flush()
+
+		fmt.Printf("\n%d lien(s) affiché(s) sur %d (offset %d).\n", len(page.Links), page.Total, page.Offset)
+	},
+}
+
+func init() {
+	ListCmd.Flags().IntVar(&listLimitFlag, "limit", 20, "Nombre maximal de liens à afficher")
+	ListCmd.Flags().IntVar(&listOffsetFlag, "offset", 0, "Nombre de liens à ignorer (pagination)")
+	ListCmd.Flags().StringVar(&listSearchFlag, "search", "", "Texte à rechercher dans le code court ou l'URL longue")
+
+	cmd2.RootCmd.AddCommand(ListCmd)
+}
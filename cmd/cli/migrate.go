@@ -7,8 +7,6 @@ import (
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 var MigrateCmd = &cobra.Command{
@@ -18,24 +16,10 @@ var MigrateCmd = &cobra.Command{
 et exécute les migrations automatiques de GORM pour créer les tables 'links' et 'clicks'
 basées sur les modèles Go.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		cfg := cmd2.Cfg
-        if cfg == nil {
-            log.Fatalf("FATAL: Impossible de charger la configuration globale.")
-        }
-
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
-        if err != nil {
-            log.Fatalf("FATAL: Impossible d'ouvrir la base de données: %v", err)
-        }
-
-		sqlDB, err := db.DB()
-		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
-		}
-		
-		defer sqlDB.Close()
+		db, closeDB := openDatabase()
+		defer closeDB()
 
-		err = db.AutoMigrate(&models.Link{}, &models.Click{})
+		err := db.AutoMigrate(&models.Link{}, &models.Click{})
         if err != nil {
             log.Fatalf("FATAL: Erreur lors de la migration: %v", err)
         }
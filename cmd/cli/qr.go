@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var (
+	qrCodeFlag   string
+	qrOutFlag    string
+	qrFormatFlag string
+	qrSizeFlag   int
+	qrMarginFlag int
+	qrLevelFlag  string
+	qrFgFlag     string
+	qrBgFlag     string
+)
+
+var QRCmd = &cobra.Command{
+	Use:   "qr",
+	Short: "Génère le QR code d'un lien court dans un fichier PNG ou SVG.",
+	Long: `Cette commande génère le QR code de l'URL courte complète d'un lien et l'écrit dans
+le fichier donné par --out. Le format est déduit de l'extension du fichier (.svg pour SVG,
+PNG sinon), sauf si --format est précisé.
+
+Exemple:
+  url-shortener qr --code="xyz123" --out=xyz123.png
+  url-shortener qr --code="xyz123" --out=xyz123.svg --level=H --fg="#1e3a8a"
+  url-shortener qr --code="xyz123" --out=affiche.png --size=1024 --margin=2`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defaults := services.DefaultQROptions()
+		opts := services.QROptions{
+			Format:     qrFormatFlag,
+			Size:       qrSizeFlag,
+			Margin:     qrMarginFlag,
+			Level:      qrLevelFlag,
+			Foreground: qrFgFlag,
+			Background: qrBgFlag,
+		}
+		if opts.Format == "" {
+			opts.Format = defaults.Format
+			if strings.EqualFold(filepath.Ext(qrOutFlag), ".svg") {
+				opts.Format = services.QRFormatSVG
+			}
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		linkService := newLinkService(db)
+
+		link, err := linkService.GetLinkByShortCode(qrCodeFlag)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				fmt.Fprintf(os.Stderr, "Aucun lien trouvé pour le code court: %s\n", qrCodeFlag)
+			} else {
+				fmt.Fprintf(os.Stderr, "Erreur lors de la récupération du lien: %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		// Une seule image est générée : le cache est inutile.
+		shortURL := cmd2.Cfg.Server.BaseURL + "/" + link.Shortcode
+		image, err := services.NewQRGenerator(0, cmd2.Cfg.QRCode.MaxSize).Generate(shortURL, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors de la génération du QR code: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := os.WriteFile(qrOutFlag, image.Data, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors de l'écriture du fichier: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("QR code de %s écrit dans %s (%s, %d octets).\n", shortURL, qrOutFlag, image.ContentType, len(image.Data))
+	},
+}
+
+func init() {
+	defaults := services.DefaultQROptions()
+	QRCmd.Flags().StringVar(&qrCodeFlag, "code", "", "Code court du lien")
+	QRCmd.Flags().StringVar(&qrOutFlag, "out", "", "Fichier de sortie (ex: qr.png ou qr.svg)")
+	QRCmd.Flags().StringVar(&qrFormatFlag, "format", "", "Format de l'image : png ou svg (déduit de --out par défaut)")
+	QRCmd.Flags().IntVar(&qrSizeFlag, "size", defaults.Size, "Côté de l'image en pixels, marge comprise")
+	QRCmd.Flags().IntVar(&qrMarginFlag, "margin", defaults.Margin, "Marge autour du symbole, en modules")
+	QRCmd.Flags().StringVar(&qrLevelFlag, "level", defaults.Level, "Niveau de correction d'erreurs : L, M, Q ou H")
+	QRCmd.Flags().StringVar(&qrFgFlag, "fg", defaults.Foreground, "Couleur des modules (#rgb, #rrggbb ou #rrggbbaa)")
+	QRCmd.Flags().StringVar(&qrBgFlag, "bg", defaults.Background, "Couleur du fond (#rgb, #rrggbb ou #rrggbbaa)")
+	QRCmd.MarkFlagRequired("code")
+	QRCmd.MarkFlagRequired("out")
+
+	cmd2.RootCmd.AddCommand(QRCmd)
+}
@@ -1,17 +1,14 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"os"
+	"time"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
-	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/spf13/cobra"
-
-	"gorm.io/driver/sqlite" 
-	"gorm.io/gorm"
 )
 
 var shortCodeFlag string
@@ -31,32 +28,14 @@ Exemple:
         }
 
 
-		cfg := cmd2.Cfg
-        if cfg == nil {
-            fmt.Fprintln(os.Stderr, "ERREUR: Impossible de charger la configuration globale.")
-            os.Exit(1)
-        }
-
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
-        if err != nil {
-            log.Fatalf("FATAL: Impossible d'ouvrir la base de données: %v", err)
-        }
-
-
-		sqlDB, err := db.DB()
-		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
-		}
+		db, closeDB := openDatabase()
+		defer closeDB()
 
-
-		defer sqlDB.Close()
-
-		linkRepo := repository.NewLinkRepository(db)
-        linkService := services.NewLinkService(linkRepo)
+		linkService := newLinkService(db)
 
 		link, totalClicks, err := linkService.GetLinkStats(shortCodeFlag)
         if err != nil {
-            if err == gorm.ErrRecordNotFound {
+            if errors.Is(err, services.ErrNotFound) {
                 fmt.Fprintf(os.Stderr, "Aucun lien trouvé pour le code court: %s\n", shortCodeFlag)
             } else {
                 fmt.Fprintf(os.Stderr, "Erreur lors de la récupération des statistiques: %v\n", err)
@@ -67,7 +46,34 @@ Exemple:
 
 		fmt.Printf("Statistiques pour le code court: %s\n", link.Shortcode)
 		fmt.Printf("URL longue: %s\n", link.LongURL)
+		if link.Metadata != nil && link.Metadata.Title != "" {
+			fmt.Printf("Titre de la destination: %s\n", link.Metadata.Title)
+		}
 		fmt.Printf("Total de clics: %d\n", totalClicks)
+
+		lifetime := services.ComputeLifetime(link, time.Now())
+		if lifetime.ExpiresAt != nil {
+			fmt.Printf("Expire le: %s (reste %s)\n", lifetime.ExpiresAt.Format(time.RFC3339), lifetime.RemainingTime.Round(time.Second))
+		}
+		if lifetime.SingleUse {
+			fmt.Printf("Usage unique: oui\n")
+		}
+		if lifetime.MaxClicks != nil {
+			fmt.Printf("Clics restants: %d/%d\n", *lifetime.RemainingClicks, *lifetime.MaxClicks)
+		}
+		fmt.Printf("État: %s\n", services.LinkState(link, time.Now()))
+
+		if len(link.Variants) > 0 {
+			variants, err := linkService.GetVariantStats(link)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Erreur lors de la répartition des clics par variante: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("\nRépartition par variante:\n")
+			for _, v := range variants {
+				fmt.Printf("  %-12s  %6d clics  %5.1f%%  (poids %d)  %s\n", v.Name, v.Clicks, v.Share*100, v.Weight, v.URL)
+			}
+		}
 	},
 }
 
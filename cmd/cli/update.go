@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateCodeFlag    string
+	updateURLFlag     string
+	updateStatusFlag  int
+	updateHeaderFlags []string
+	clearHeadersFlag  bool
+	updateFwdQuery    bool
+	updateConflict    string
+	updateFwdPath     bool
+	updatePassword    string
+	removePassword    bool
+	updateActivateAt  string
+	updateFallbackURL string
+	disableFlag       bool
+	enableFlag        bool
+	updateVariants    []string
+	clearVariants     bool
+	updateSticky      bool
+	updateTargets     []string
+	clearTargets      bool
+	updateLangs       []string
+	clearLangs        bool
+	updateTimeRules   []string
+	clearTimeRules    bool
+	updateRules       []string
+	clearRules        bool
+)
+
+var UpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Modifie l'URL de destination ou la redirection d'un lien court.",
+	Long: `Cette commande remplace l'URL longue associée à un code court existant,
+ainsi que son statut de redirection et ses en-têtes de réponse.
+
+Exemple:
+  url-shortener update --code="xyz123" --url="https://www.example.com/nouvelle-page"
+  url-shortener update --code="xyz123" --status=308 --header="Cache-Control: public, max-age=604800"
+  url-shortener update --code="xyz123" --status=0 --clear-headers
+  url-shortener update --code="xyz123" --forward-query=true --query-conflict=append --forward-path=false
+  url-shortener update --code="xyz123" --password="nouveau-secret"
+  url-shortener update --code="xyz123" --remove-password
+  url-shortener update --code="xyz123" --activate-at="2030-03-01T09:00:00Z" --fallback-url="https://example.com/bientot"
+  url-shortener update --code="xyz123" --disable
+  url-shortener update --code="xyz123" --variant="a:50:https://example.com/v1" --variant="b:50:https://example.com/v2" --sticky=true
+  url-shortener update --code="xyz123" --clear-variants --url="https://example.com/gagnante"
+  url-shortener update --code="xyz123" --target="os=ios->https://apps.apple.com/app/id123" --target="os=android->https://play.google.com/store/apps/details?id=com.example"
+  url-shortener update --code="xyz123" --clear-targets
+  url-shortener update --code="xyz123" --lang="fr=https://docs.example.com/fr/" --lang="de=https://docs.example.com/de/"
+  url-shortener update --code="xyz123" --time-rule="sat,sun 00:00-00:00 Europe/Paris->https://example.com/week-end"
+  url-shortener update --code="xyz123" --clear-time-rules
+  url-shortener update --code="xyz123" --rule='cookie("plan") == "pro"->https://example.com/pro'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var update services.LinkUpdate
+		if cmd.Flags().Changed("url") {
+			update.LongURL = &updateURLFlag
+		}
+		if cmd.Flags().Changed("status") {
+			update.RedirectStatus = &updateStatusFlag
+		}
+		if len(updateHeaderFlags) > 0 || clearHeadersFlag {
+			headers, err := parseHeaderFlags(updateHeaderFlags)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+			if headers == nil {
+				headers = map[string]string{}
+			}
+			update.Headers = &headers
+		}
+		if cmd.Flags().Changed("forward-query") {
+			update.ForwardQuery = &updateFwdQuery
+		}
+		if cmd.Flags().Changed("query-conflict") {
+			update.QueryConflict = &updateConflict
+		}
+		if cmd.Flags().Changed("forward-path") {
+			update.ForwardPath = &updateFwdPath
+		}
+		if cmd.Flags().Changed("password") {
+			update.Password = &updatePassword
+		} else if removePassword {
+			noPassword := ""
+			update.Password = &noPassword
+		}
+		if updateActivateAt != "" {
+			activateAt, err := time.Parse(time.RFC3339, updateActivateAt)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Date d'activation invalide (format RFC 3339 attendu): %v\n", err)
+				os.Exit(1)
+			}
+			update.ActivateAt = &activateAt
+		}
+		if cmd.Flags().Changed("fallback-url") {
+			update.FallbackURL = &updateFallbackURL
+		}
+		if disableFlag || enableFlag {
+			update.Disabled = &disableFlag
+		}
+		if len(updateVariants) > 0 || clearVariants {
+			variants, err := parseVariantFlags(updateVariants)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if variants == nil {
+				variants = []models.LinkVariant{}
+			}
+			update.Variants = &variants
+		}
+		if cmd.Flags().Changed("sticky") {
+			update.StickyVariants = &updateSticky
+		}
+		if len(updateTargets) > 0 || clearTargets {
+			rules, err := parseTargetFlags(updateTargets)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if rules == nil {
+				rules = []models.TargetingRule{}
+			}
+			update.Targeting = &rules
+		}
+		if len(updateLangs) > 0 || clearLangs {
+			localized, err := parseLangFlags(updateLangs)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if localized == nil {
+				localized = map[string]string{}
+			}
+			update.Localized = &localized
+		}
+		if len(updateTimeRules) > 0 || clearTimeRules {
+			rules, err := parseTimeRuleFlags(updateTimeRules)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if rules == nil {
+				rules = []models.TimeRule{}
+			}
+			update.TimeRules = &rules
+		}
+		if len(updateRules) > 0 || clearRules {
+			list, err := parseRuleFlags(updateRules)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if list == nil {
+				list = []models.RedirectRule{}
+			}
+			update.RedirectRules = &list
+		}
+		if update == (services.LinkUpdate{}) {
+			fmt.Fprintln(os.Stderr, "Rien à modifier : indiquez au moins une option (--url, --status, --header, --forward-query...).")
+			os.Exit(1)
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		linkService := newLinkService(db)
+
+		link, err := linkService.UpdateLink(updateCodeFlag, update)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				fmt.Fprintf(os.Stderr, "Aucun lien trouvé pour le code court: %s\n", updateCodeFlag)
+			} else {
+				fmt.Fprintf(os.Stderr, "Erreur lors de la mise à jour du lien: %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("Lien mis à jour avec succès:\n")
+		fmt.Printf("Code: %s\n", link.Shortcode)
+		fmt.Printf("URL longue: %s\n", link.LongURL)
+		if link.RedirectStatus != 0 {
+			fmt.Printf("Statut de redirection: %d\n", link.RedirectStatus)
+		}
+		for name, value := range link.ResponseHeaders {
+			fmt.Printf("En-tête: %s: %s\n", name, value)
+		}
+		if link.ForwardQuery {
+			fmt.Printf("Transfert de la query string: oui (conflits: %s)\n", queryConflictLabel(link.QueryConflict))
+		}
+		if link.ForwardPath {
+			fmt.Printf("Transfert du chemin: oui\n")
+		}
+		if services.IsPasswordProtected(link) {
+			fmt.Printf("Protégé par mot de passe: oui\n")
+		}
+		printSchedule(link)
+		printVariants(link)
+		printTargeting(link)
+		printLocalized(link)
+		printTimeRules(link)
+		printRedirectRules(link)
+	},
+}
+
+func init() {
+	UpdateCmd.Flags().StringVar(&updateCodeFlag, "code", "", "Code court du lien à modifier")
+	UpdateCmd.Flags().StringVar(&updateURLFlag, "url", "", "Nouvelle URL longue")
+	UpdateCmd.Flags().IntVar(&updateStatusFlag, "status", 0, "Nouveau statut de redirection : 301, 302, 307, 308, ou 0 pour le statut global")
+	UpdateCmd.Flags().StringArrayVar(&updateHeaderFlags, "header", nil, "En-tête de redirection au format \"Nom: valeur\" (répétable, remplace les en-têtes existants)")
+	UpdateCmd.Flags().BoolVar(&clearHeadersFlag, "clear-headers", false, "Retire tous les en-têtes propres au lien")
+
+	UpdateCmd.Flags().BoolVar(&updateFwdQuery, "forward-query", false, "Ajouter (true) ou non (false) les paramètres de la requête à l'URL longue")
+	UpdateCmd.Flags().StringVar(&updateConflict, "query-conflict", "", "Politique de conflit des paramètres : destination, request ou append")
+	UpdateCmd.Flags().BoolVar(&updateFwdPath, "forward-path", false, "Ajouter (true) ou non (false) le chemin qui suit le code court à l'URL longue")
+
+	UpdateCmd.Flags().StringVar(&updatePassword, "password", "", "Nouveau mot de passe du lien")
+	UpdateCmd.Flags().BoolVar(&removePassword, "remove-password", false, "Retire la protection par mot de passe")
+	UpdateCmd.MarkFlagsMutuallyExclusive("password", "remove-password")
+
+	UpdateCmd.Flags().StringVar(&updateActivateAt, "activate-at", "", "Nouvelle date d'activation au format RFC 3339")
+	UpdateCmd.Flags().StringVar(&updateFallbackURL, "fallback-url", "", "Nouvelle URL de repli (vide pour la retirer)")
+	UpdateCmd.Flags().BoolVar(&disableFlag, "disable", false, "Désactive le lien")
+	UpdateCmd.Flags().BoolVar(&enableFlag, "enable", false, "Réactive le lien")
+	UpdateCmd.MarkFlagsMutuallyExclusive("disable", "enable")
+
+	UpdateCmd.Flags().StringArrayVar(&updateVariants, "variant", nil, "Destination d'un test A/B au format \"nom:poids:url\" (répétable, remplace les variantes existantes)")
+	UpdateCmd.Flags().BoolVar(&clearVariants, "clear-variants", false, "Retire les variantes : le lien redirige à nouveau vers son URL longue")
+	UpdateCmd.MarkFlagsMutuallyExclusive("variant", "clear-variants")
+	UpdateCmd.Flags().StringArrayVar(&updateTargets, "target", nil, "Règle de ciblage par appareil au format \"os=ios,device=mobile->url\" (répétable, remplace les règles existantes)")
+	UpdateCmd.Flags().BoolVar(&clearTargets, "clear-targets", false, "Retire les règles de ciblage par appareil")
+	UpdateCmd.MarkFlagsMutuallyExclusive("target", "clear-targets")
+	UpdateCmd.Flags().StringArrayVar(&updateLangs, "lang", nil, "Destination pour une langue au format \"fr-CA=url\" (répétable, remplace les destinations existantes)")
+	UpdateCmd.Flags().BoolVar(&clearLangs, "clear-langs", false, "Retire les destinations par langue")
+	UpdateCmd.MarkFlagsMutuallyExclusive("lang", "clear-langs")
+	UpdateCmd.Flags().StringArrayVar(&updateTimeRules, "time-rule", nil, "Destination pendant une plage horaire au format \"mon,tue 09:00-18:00 Europe/Paris->url\" (répétable, remplace les règles existantes)")
+	UpdateCmd.Flags().BoolVar(&clearTimeRules, "clear-time-rules", false, "Retire les règles horaires")
+	UpdateCmd.MarkFlagsMutuallyExclusive("time-rule", "clear-time-rules")
+	UpdateCmd.Flags().StringArrayVar(&updateRules, "rule", nil, "Règle de redirection au format \"condition->url\" (répétable, remplace les règles existantes)")
+	UpdateCmd.Flags().BoolVar(&clearRules, "clear-rules", false, "Retire les règles de redirection")
+	UpdateCmd.MarkFlagsMutuallyExclusive("rule", "clear-rules")
+	UpdateCmd.Flags().BoolVar(&updateSticky, "sticky", false, "Servir (true) ou non (false) toujours la même variante à un visiteur")
+
+	UpdateCmd.MarkFlagRequired("code")
+
+	cmd2.RootCmd.AddCommand(UpdateCmd)
+}
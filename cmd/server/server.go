@@ -12,6 +12,8 @@ import (
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
 	"github.com/axellelanca/urlshortener/internal/api"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/metadata"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/monitor"
 	"github.com/axellelanca/urlshortener/internal/repository"
@@ -19,8 +21,6 @@ import (
 	"github.com/axellelanca/urlshortener/internal/workers"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite" // Driver SQLite pour GORM
-	"gorm.io/gorm"
 )
 
 // RunServerCmd représente la commande 'run-server' de Cobra.
@@ -39,7 +39,7 @@ puis lance le serveur HTTP.`,
 		}
 
 	
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		db, err := database.Open(cfg.Database.Name)
 		if err != nil {
 			log.Fatalf("Erreur lors de la connexion à la base de données : %v", err)
 		}
@@ -53,18 +53,44 @@ puis lance le serveur HTTP.`,
 		log.Println("Repositories initialisés.")
 
 	
-		linkService := services.NewLinkService(linkRepo)
-		clickService := services.NewClickService(clickRepo)
+		destinationPolicy, err := services.LoadDestinationPolicyFromConfig(cfg)
+		if err != nil {
+			log.Fatalf("Politique de domaines invalide : %v", err)
+		}
+		if cfg.DestinationPolicy.HotReload {
+			if err := destinationPolicy.Watch(); err != nil {
+				log.Printf("Rechargement à chaud de la politique de domaines indisponible : %v", err)
+			}
+		}
+
+		linkOptions := []services.LinkServiceOption{services.WithDestinationPolicy(destinationPolicy)}
+		var metadataRequests chan models.MetadataRequest
+		if cfg.Metadata.Enabled {
+			// Les liens créés sont transmis aux workers de récupération des informations de destination.
+			metadataRequests = make(chan models.MetadataRequest, cfg.Metadata.QueueSize)
+			linkOptions = append(linkOptions, services.WithMetadataQueue(metadataRequests))
+		}
+
+		linkService, err := services.NewLinkServiceFromConfig(linkRepo, cfg, linkOptions...)
+		if err != nil {
+			log.Fatalf("Configuration du service de liens invalide : %v", err)
+		}
+
+		// Les liens créés avant l'ajout d'un mot réservé restent en base : on les signale.
+		linkService.WarnReservedCollisions()
 
 		// Laissez le log
 		log.Println("Services métiers initialisés.")
 
 
-		clickEventsChannel := make(chan models.ClickEvent, cfg.Workers.ClickEventsBufferSize)
+		// Les redirections publient les clics sans attendre : le buffer absorbe les pics de charge.
+		clickEventsChannel := make(chan models.ClickEvent, cfg.Analytics.BufferSize)
 		workers.StartClickWorkers(cfg.Analytics.WorkerCount, clickEventsChannel, clickRepo)
+		// Les redirections publient les clics sur ce même channel.
+		api.ClickEventsChannel = clickEventsChannel
 
 		log.Printf("Channel d'événements de clic initialisé avec un buffer de %d. %d worker(s) de clics démarré(s).",
-			cfg.Workers.ClickEventsBufferSize, cfg.Analytics.WorkerCount)
+			cfg.Analytics.BufferSize, cfg.Analytics.WorkerCount)
 
 	
 		monitorInterval := time.Duration(
@@ -77,10 +103,27 @@ puis lance le serveur HTTP.`,
 		go urlMonitor.Start()
 		log.Printf("Moniteur d'URLs démarré avec un intervalle de %v.", monitorInterval)
 
+		sweepInterval := time.Duration(cfg.Expiration.SweepIntervalMinutes) * time.Minute
+		workers.StartExpirationSweeper(sweepInterval, linkRepo)
+
+		if metadataRequests != nil {
+			fetcher := metadata.NewFetcher(time.Duration(cfg.Metadata.TimeoutSeconds)*time.Second, cfg.Metadata.MaxBytes)
+			workers.StartMetadataWorkers(cfg.Metadata.WorkerCount, metadataRequests, linkRepo, fetcher, workers.MetadataSchedule{
+				Refresh: time.Duration(cfg.Metadata.RefreshHours) * time.Hour,
+				Retry:   time.Duration(cfg.Metadata.RetryMinutes) * time.Minute,
+			})
+			workers.StartMetadataScheduler(time.Duration(cfg.Metadata.ScanIntervalMinutes)*time.Minute, linkRepo, metadataRequests)
+		}
+
 	
 		router := gin.Default()
+		// Sans proxy déclaré, l'IP du client est l'adresse de la connexion : un en-tête X-Forwarded-For
+		// forgé ne doit pas permettre de contourner la limite des tentatives de mot de passe.
+		if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+			log.Fatalf("Liste des proxys de confiance invalide : %v", err)
+		}
 
-		api.SetupRoutes(router, linkService, clickService)
+		api.SetupRoutes(router, linkService, urlMonitor)
 
 		// Pas toucher au log
 		log.Println("Routes API configurées.")
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// KeyspaceHandler expose l'occupation de l'espace des codes courts pour chaque longueur,
+// ainsi que les statistiques de collisions utilisées pour l'allongement automatique des codes.
+func KeyspaceHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := linkService.GetKeyspaceReport()
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
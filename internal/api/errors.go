@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// problemContentType est le type de contenu des réponses d'erreur (RFC 7807).
+const problemContentType = "application/problem+json"
+
+// Codes d'erreur stables utilisés lorsque l'erreur ne provient pas de la couche service.
+const (
+	codeInvalidRequest = "invalid_request"
+	codeRouteNotFound  = "route_not_found"
+	codeInternalError  = "internal_error"
+)
+
+// Problem est le corps d'une réponse d'erreur au format RFC 7807 ("Problem Details for HTTP APIs").
+// Le membre d'extension 'code' porte un identifiant stable sur lequel les clients peuvent s'appuyer.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+}
+
+// respondError traduit une erreur renvoyée par la couche service en réponse problem+json.
+// Les erreurs qui ne sont pas des erreurs du domaine sont journalisées et renvoyées
+// comme des erreurs internes, sans exposer leur détail au client.
+func respondError(c *gin.Context, err error) {
+	status := statusForError(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("Internal error on %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		respondProblem(c, status, codeInternalError, "")
+		return
+	}
+
+	respondProblem(c, status, services.ErrorCode(err), err.Error())
+}
+
+// respondProblem écrit une réponse problem+json et interrompt la chaîne de handlers.
+func respondProblem(c *gin.Context, status int, code, detail string) {
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(status, Problem{
+		Type:     "urn:url-shortener:error:" + code,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+		Code:     code,
+	})
+}
+
+// respondInvalidRequest signale une requête mal formée (corps JSON ou paramètres invalides).
+func respondInvalidRequest(c *gin.Context, detail string) {
+	respondProblem(c, http.StatusBadRequest, codeInvalidRequest, detail)
+}
+
+// statusForError associe chaque catégorie d'erreur du domaine à un statut HTTP.
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, services.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrExpired):
+		return http.StatusGone
+	case errors.Is(err, services.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// NoRouteHandler renvoie une erreur problem+json pour les routes inconnues.
+func NoRouteHandler(c *gin.Context) {
+	respondProblem(c, http.StatusNotFound, codeRouteNotFound, "no route matches "+c.Request.URL.Path)
+}
+
+// respondUnavailable répond à la visite d'un lien programmé qui n'est pas encore disponible :
+// redirection vers l'URL de repli du lien (ou l'URL de repli globale) si elle existe, sinon
+// réponse problem+json avec le statut configuré et un en-tête Retry-After lorsque la date
+// de disponibilité est connue.
+func respondUnavailable(c *gin.Context, info services.LinkUnavailable, err error) {
+	fallback := info.FallbackURL
+	if fallback == "" {
+		fallback = cmd2.Cfg.Scheduling.FallbackURL
+	}
+	if fallback != "" {
+		c.Header("Cache-Control", "no-store")
+		c.Redirect(http.StatusFound, fallback)
+		c.Abort()
+		return
+	}
+
+	if info.AvailableAt != nil {
+		if wait := time.Until(*info.AvailableAt); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		}
+	}
+	status := cmd2.Cfg.Scheduling.UnavailableStatus
+	if status == 0 {
+		status = http.StatusServiceUnavailable
+	}
+	respondProblem(c, status, services.ErrorCode(err), err.Error())
+}
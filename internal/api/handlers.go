@@ -8,17 +8,19 @@ import (
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
 	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/monitor"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm" // Pour gérer gorm.ErrRecordNotFound
 )
 
 
-
-var ClickEventsChannel chan *models.ClickEvent
+// ClickEventsChannel transmet les clics aux workers qui les enregistrent en base.
+// Le serveur le renseigne avant SetupRoutes avec le channel lu par les workers.
+var ClickEventsChannel chan models.ClickEvent
 
 // SetupRoutes configure toutes les routes de l'API Gin et injecte les dépendances nécessaires
-func SetupRoutes(router *gin.Engine, linkService *services.LinkService, clickService *services.ClickService) {
+// Le moniteur d'URLs fournit l'état des destinations montré par les aperçus ; il peut être nil.
+func SetupRoutes(router *gin.Engine, linkService *services.LinkService, urlMonitor *monitor.UrlMonitor) {
 	cfg := cmd2.Cfg
 	if cfg == nil {
 		log.Fatal("Configuration non chargée. Veuillez vérifier la configuration.")
@@ -26,17 +28,36 @@ func SetupRoutes(router *gin.Engine, linkService *services.LinkService, clickSer
 	
 	// Le channel est initialisé ici.
 	if ClickEventsChannel == nil {
-		ClickEventsChannel = make(chan *models.ClickEvent, cfg.Analytics.BufferSize)
+		ClickEventsChannel = make(chan models.ClickEvent, cfg.Analytics.BufferSize)
 	}
 
 	router.GET("/health", HealthCheckHandler)
 
 
 	router.POST("/api/v1/links", CreateShortLinkHandler(linkService))
+	router.GET("/api/v1/links", ListLinksHandler(linkService))
+	router.PATCH("/api/v1/links/:shortCode", UpdateLinkHandler(linkService))
+	router.DELETE("/api/v1/links/:shortCode", DeleteLinkHandler(linkService))
 	router.GET("/api/v1/links/:shortCode/stats", GetLinkStatsHandler(linkService))
+	router.POST("/api/v1/links/:shortCode/rules/dry-run", DryRunRulesHandler(linkService))
+	router.GET("/api/v1/links/:shortCode/preview", PreviewHandler(linkService, urlMonitor))
+	qrGenerator := services.NewQRGenerator(cfg.QRCode.CacheSize, cfg.QRCode.MaxSize)
+	router.GET("/api/v1/links/:shortCode/qr", QRCodeHandler(linkService, qrGenerator))
+
+	// Routes d'administration
+	router.GET("/api/v1/admin/keyspace", KeyspaceHandler(linkService))
 
-	// Route de Redirection (au niveau racine pour les short codes)
-	router.GET("/:shortCode", RedirectHandler(linkService, clickService))
+	// Route de Redirection (au niveau racine pour les short codes). La seconde route capture
+	// le chemin qui suit le code, transféré à la destination si le lien l'autorise.
+	// Un "+" après le code ("/abc123+") affiche l'aperçu du lien au lieu de rediriger.
+	router.GET("/:shortCode", RedirectHandler(linkService, urlMonitor))
+	router.GET("/:shortCode/*rest", RedirectHandler(linkService, urlMonitor))
+	// Saisie du mot de passe des liens protégés
+	router.POST("/:shortCode", UnlockLinkHandler(linkService))
+	router.POST("/:shortCode/*rest", UnlockLinkHandler(linkService))
+
+	// Toutes les erreurs, y compris les routes inconnues, suivent le format problem+json.
+	router.NoRoute(NoRouteHandler)
 }
 
 // HealthCheckHandler gère la route /health pour vérifier l'état du service.
@@ -46,7 +67,42 @@ func HealthCheckHandler(c *gin.Context) {
 
 // CreateLinkRequest représente le corps de la requête JSON pour la création d'un lien.
 type CreateLinkRequest struct {
-	LongURL string `json:"long_url" binding:"required,url"` // 'binding:required' pour validation, 'url' pour format URL
+	LongURL string `json:"long_url" binding:"required_without=Variants,omitempty,url"` // Requise sauf pour un test A/B
+	Alias   string `json:"alias,omitempty"`                 // Alias personnalisé optionnel, validé par le service
+	// Paramètres d'expiration optionnels : date limite (RFC 3339) et budget de clics.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	MaxClicks *int       `json:"max_clicks,omitempty"`
+	// Lien à usage unique ("burn after reading") : toute visite après la première reçoit 410 Gone.
+	Once bool `json:"once,omitempty"`
+	// Statut de redirection (301, 302, 307, 308) et en-têtes de réponse propres au lien (Cache-Control...).
+	RedirectStatus int               `json:"redirect_status,omitempty"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	// Transfert vers la destination des paramètres de la requête et du chemin qui suit le code court.
+	// query_conflict : destination (par défaut), request ou append.
+	ForwardQuery  bool   `json:"forward_query,omitempty"`
+	QueryConflict string `json:"query_conflict,omitempty"`
+	ForwardPath   bool   `json:"forward_path,omitempty"`
+	// Mot de passe demandé aux visiteurs avant la redirection (optionnel)
+	Password string `json:"password,omitempty"`
+	// Programmation : date d'activation (RFC 3339), plage de disponibilité récurrente et URL servie
+	// tant que le lien n'est pas disponible.
+	ActivateAt   *time.Time                 `json:"activate_at,omitempty"`
+	Availability *models.AvailabilityWindow `json:"availability,omitempty"`
+	FallbackURL  string                     `json:"fallback_url,omitempty" binding:"omitempty,url"`
+	// Destinations pondérées d'un test A/B, à la place de long_url. sticky_variants sert toujours
+	// la même variante à un visiteur.
+	Variants       []models.LinkVariant `json:"variants,omitempty"`
+	StickyVariants bool                 `json:"sticky_variants,omitempty"`
+	// Règles de ciblage par appareil (os, device, browser → url), évaluées dans l'ordre.
+	Targeting []models.TargetingRule `json:"targeting,omitempty"`
+	// Destinations par langue ("fr": url, "fr-CA": url...), négociées avec l'en-tête Accept-Language.
+	Localized map[string]string `json:"localized,omitempty"`
+	// Destinations par plage horaire (days, start, end, timezone → url), évaluées dans l'ordre.
+	TimeRules []models.TimeRule `json:"time_rules,omitempty"`
+	// Règles "condition → url" écrites dans le langage d'expressions, évaluées avant toutes les autres.
+	Rules []models.RedirectRule `json:"rules,omitempty"`
+	// Si vrai, un lien existant vers la même destination est renvoyé (200) au lieu d'en créer un nouveau (201).
+	ReuseExisting bool `json:"reuse_existing,omitempty"`
 }
 
 // CreateShortLinkHandler gère la création d'une URL courte.
@@ -61,74 +117,141 @@ func CreateShortLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
 
 		if err := c.ShouldBindJSON(&req); err != nil {
 			// Si la validation échoue, retourne une erreur 400 Bad Request avec le message
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
+			respondInvalidRequest(c, "Invalid request data: "+err.Error())
 			return
 		}
 
 
-		link, err := linkService.CreateLink(req.LongURL)
+		opts := services.CreateLinkOptions{
+			Alias:     req.Alias,
+			ExpiresAt: req.ExpiresAt,
+			MaxClicks: req.MaxClicks,
+			Once:      req.Once,
+
+			RedirectStatus: req.RedirectStatus,
+			Headers:        req.Headers,
+
+			ForwardQuery:  req.ForwardQuery,
+			QueryConflict: req.QueryConflict,
+			ForwardPath:   req.ForwardPath,
+
+			Password: req.Password,
+
+			ActivateAt:   req.ActivateAt,
+			Availability: req.Availability,
+			FallbackURL:  req.FallbackURL,
+
+			Variants:       req.Variants,
+			StickyVariants: req.StickyVariants,
+
+			Targeting: req.Targeting,
+			Localized: req.Localized,
+			TimeRules: req.TimeRules,
+
+			RedirectRules: req.Rules,
+		}
+
+		var link *models.Link
+		var err error
+		created := true
+		if req.ReuseExisting {
+			link, created, err = linkService.FindOrCreateLink(req.LongURL, opts)
+		} else {
+			link, err = linkService.CreateLink(req.LongURL, opts)
+		}
 		if err != nil {
-			log.Printf("Error creating link for %s: %v", req.LongURL, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			// Alias invalide ou réservé (400), alias déjà pris (409), erreur interne (500)...
+			respondError(c, err)
 			return
 		}
 
+		status := http.StatusCreated
+		if !created {
+			status = http.StatusOK
+		}
+
 		// Retourne le code court et l'URL longue dans la réponse JSON.
-		c.JSON(http.StatusCreated, gin.H{
+		c.JSON(status, gin.H{
 			"short_code":     link.Shortcode,
 			"long_url":       link.LongURL,
-			"full_short_url": cfg.Server.BaseURL + link.Shortcode, 
+			"full_short_url": cfg.Server.BaseURL + "/" + link.Shortcode,
 		})
 	}
 }
 
 // RedirectHandler gère la redirection d'une URL courte vers l'URL longue et l'enregistrement asynchrone des clics.
-func RedirectHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
+func RedirectHandler(linkService *services.LinkService, urlMonitor *monitor.UrlMonitor) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Récupère le shortCode de l'URL avec c.Param
-		shortCode := c.Param("shortCode")
-
-
-		link, err := linkService.GetLinkByShortCode(shortCode)
+		shortCode, preview := previewRequested(c)
+		if preview {
+			// "/abc123+" ou "?preview=1" : page d'aperçu, sans redirection ni clic.
+			renderPreview(c, linkService, urlMonitor, shortCode)
+			return
+		}
 
+		resolution, err := linkService.ResolveLink(shortCode, requestVisit(c))
+		if info, unavailable := services.Unavailability(err); unavailable {
+			// Lien programmé : URL de repli si elle existe, réponse "pas encore disponible" sinon.
+			respondUnavailable(c, info, err)
+			return
+		}
+		if errors.Is(err, services.ErrPasswordRequired) {
+			// Lien protégé : le mot de passe est demandé avant toute redirection ou enregistrement du clic.
+			renderPasswordPrompt(c, http.StatusUnauthorized, passwordPrompt{})
+			return
+		}
 		if err != nil {
-			// Si le lien n'est pas trouvé, retourner HTTP 404 Not Found.
-			// Utiliser errors.Is et l'erreur Gorm
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
-				return
-			}
-			// Gérer d'autres erreurs potentielles de la base de données ou du service
-			log.Printf("Error retrieving link for %s: %v", shortCode, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			// Lien introuvable : 404 Not Found. Lien expiré (date ou budget de clics) ou désactivé : 410 Gone.
+			respondError(c, err)
 			return
 		}
+		link, destination := resolution.Link, resolution.Destination
+		if link.StickyVariants && resolution.Variant != "" {
+			setStickyVariant(c, link.Shortcode, resolution.Variant)
+		}
 
-		clickEvent := &models.ClickEvent{
+		clickEvent := models.ClickEvent{
 			LinkID:    link.ID,
 			Timestamp: time.Now(),
 			UserAgent: c.Request.UserAgent(),
 			IPAddress: c.ClientIP(),
+			Variant:   resolution.Variant,
+			Language:  resolution.Language,
 		}
 
-		
-
+		// L'envoi est non bloquant : le clic est enregistré par les workers, sans retarder la redirection.
 		select {
 		case ClickEventsChannel <- clickEvent:
-			// Si l'envoi est réussi, on continue
-			click := &models.Click{
-				LinkID:    clickEvent.LinkID,
-				Timestamp: clickEvent.Timestamp,
-				UserAgent: clickEvent.UserAgent,
-				IPAddress: clickEvent.IPAddress,
-			}
-			clickService.RecordClick(click)
 		default:
 			log.Printf("Warning: ClickEventsChannel is full, dropping click event for %s.", shortCode)
 		}
 
-		c.Redirect(http.StatusFound, link.LongURL)
-		log.Printf("Redirecting short code %s to long URL %s", shortCode, link.LongURL)
+		// Statut et en-têtes propres au lien, complétés par les réglages globaux.
+		redirect := linkService.RedirectFor(link)
+		for name, values := range redirect.Headers {
+			for _, value := range values {
+				c.Writer.Header().Add(name, value)
+			}
+		}
+		c.Redirect(redirect.Status, destination)
+		log.Printf("Redirecting short code %s to long URL %s", shortCode, destination)
+	}
+}
+
+// requestVisit décrit la visite d'un lien court à partir de la requête reçue.
+func requestVisit(c *gin.Context) services.Visit {
+	return services.Visit{
+		Path:  c.Param("rest"),
+		Query: c.Request.URL.Query(),
+
+		AccessToken:    accessToken(c),
+		StickyVariant:  stickyVariant(c),
+		UserAgent:      c.Request.UserAgent(),
+		AcceptLanguage: c.GetHeader("Accept-Language"),
+		Header:         c.Request.Header,
+		Cookies:        requestCookies(c),
+		ClientIP:       c.ClientIP(),
 	}
 }
 
@@ -139,29 +262,57 @@ func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
 
 
 
-		link, err := linkService.GetLinkByShortCode(shortCode)
+		link, totalClicks, err := linkService.GetLinkStats(shortCode)
 		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
-				return
-			}
-			log.Printf("Error retrieving link stats for %s: %v", shortCode, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			respondError(c, err)
 			return
 		}
 
-		_, totalClicks, err := linkService.GetLinkStats(shortCode)
-		if err != nil {
-			log.Printf("Error retrieving total clicks for %s: %v", shortCode, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-			return
+		// Retourne les statistiques dans la réponse JSON, avec la durée de vie restante du lien.
+		response := gin.H{
+			"short_code":         link.Shortcode,
+			"long_url":           link.LongURL,
+			"total_clicks":       totalClicks,
+			"password_protected": services.IsPasswordProtected(link),
+			"state":              services.LinkState(link, time.Now()),
+		}
+		if link.ActivateAt != nil {
+			response["activate_at"] = link.ActivateAt
+		}
+		if link.Availability != nil {
+			response["availability"] = link.Availability
+		}
+		addLifetimeFields(response, services.ComputeLifetime(link, time.Now()))
+		if link.Metadata != nil {
+			response["metadata"] = link.Metadata
 		}
 
-		// Retourne les statistiques dans la réponse JSON.
-		c.JSON(http.StatusOK, gin.H{
-			"short_code":   link.Shortcode,
-			"long_url":     link.LongURL,
-			"total_clicks": totalClicks,
-		})
+		if len(link.Variants) > 0 {
+			variants, err := linkService.GetVariantStats(link)
+			if err != nil {
+				respondError(c, err)
+				return
+			}
+			response["variants"] = variants
+			response["sticky_variants"] = link.StickyVariants
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// addLifetimeFields ajoute à une réponse JSON les informations de durée de vie d'un lien.
+// Les limites non définies sont omises.
+func addLifetimeFields(response gin.H, lifetime services.LinkLifetime) {
+	response["expired"] = lifetime.Expired
+	if lifetime.SingleUse {
+		response["single_use"] = true
+	}
+	if lifetime.ExpiresAt != nil {
+		response["expires_at"] = lifetime.ExpiresAt
+		response["remaining_seconds"] = int64(lifetime.RemainingTime.Seconds())
+	}
+	if lifetime.MaxClicks != nil {
+		response["max_clicks"] = *lifetime.MaxClicks
+		response["remaining_clicks"] = *lifetime.RemainingClicks
 	}
 }
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// LinkResponse représente un lien dans les réponses JSON de l'API de gestion des liens.
+type LinkResponse struct {
+	ShortCode    string     `json:"short_code"`
+	LongURL      string     `json:"long_url"`
+	FullShortURL string     `json:"full_short_url"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	MaxClicks    *int       `json:"max_clicks,omitempty"`
+	UsedClicks   int        `json:"used_clicks"`
+	SingleUse    bool       `json:"single_use"`
+	Expired      bool       `json:"expired"`
+
+	RedirectStatus int               `json:"redirect_status,omitempty"` // Absent si le lien utilise le statut global
+	Headers        map[string]string `json:"headers,omitempty"`
+
+	ForwardQuery  bool   `json:"forward_query"`
+	QueryConflict string `json:"query_conflict,omitempty"`
+	ForwardPath   bool   `json:"forward_path"`
+
+	PasswordProtected bool `json:"password_protected"`
+
+	State        string                     `json:"state"` // scheduled, active, expired ou disabled
+	ActivateAt   *time.Time                 `json:"activate_at,omitempty"`
+	Availability *models.AvailabilityWindow `json:"availability,omitempty"`
+	FallbackURL  string                     `json:"fallback_url,omitempty"`
+
+	Variants       []models.LinkVariant `json:"variants,omitempty"`
+	StickyVariants bool                 `json:"sticky_variants,omitempty"`
+
+	Targeting []models.TargetingRule `json:"targeting,omitempty"`
+	Localized map[string]string      `json:"localized,omitempty"`
+	TimeRules []models.TimeRule      `json:"time_rules,omitempty"`
+	Rules     []models.RedirectRule  `json:"rules,omitempty"`
+
+	Metadata *models.PageMetadata `json:"metadata,omitempty"` // Informations de la page de destination, absentes avant leur récupération
+}
+
+// newLinkResponse construit la représentation JSON d'un lien.
+func newLinkResponse(link *models.Link) LinkResponse {
+	return LinkResponse{
+		ShortCode:    link.Shortcode,
+		LongURL:      link.LongURL,
+		FullShortURL: cmd2.Cfg.Server.BaseURL + "/" + link.Shortcode,
+		CreatedAt:    link.CreatedAt,
+		ExpiresAt:    link.ExpiresAt,
+		MaxClicks:    link.MaxClicks,
+		UsedClicks:   link.UsedClicks,
+		SingleUse:    link.SingleUse,
+		Expired:      services.IsLinkExpired(link, time.Now()),
+
+		RedirectStatus: link.RedirectStatus,
+		Headers:        link.ResponseHeaders,
+
+		ForwardQuery:  link.ForwardQuery,
+		QueryConflict: link.QueryConflict,
+		ForwardPath:   link.ForwardPath,
+
+		PasswordProtected: services.IsPasswordProtected(link),
+
+		State:        services.LinkState(link, time.Now()),
+		ActivateAt:   link.ActivateAt,
+		Availability: link.Availability,
+		FallbackURL:  link.FallbackURL,
+
+		Variants:       link.Variants,
+		StickyVariants: link.StickyVariants,
+
+		Targeting: link.TargetingRules,
+		Localized: link.Localized,
+		TimeRules: link.TimeRules,
+		Rules:     link.RedirectRules,
+
+		Metadata: link.Metadata,
+	}
+}
+
+// ListLinksHandler gère le listing paginé des liens.
+// Paramètres de requête : limit, offset, created_after, created_before (RFC 3339), domain, search
+// et sort (ex: sort=-created_at pour un tri décroissant).
+func ListLinksHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := services.ListLinksQuery{
+			Domain: c.Query("domain"),
+			Search: c.Query("search"),
+			Sort:   c.Query("sort"),
+		}
+
+		var err error
+		if query.Limit, err = intQuery(c, "limit"); err != nil {
+			respondInvalidRequest(c, "Invalid limit: "+err.Error())
+			return
+		}
+		if query.Offset, err = intQuery(c, "offset"); err != nil {
+			respondInvalidRequest(c, "Invalid offset: "+err.Error())
+			return
+		}
+		if query.CreatedAfter, err = timeQuery(c, "created_after"); err != nil {
+			respondInvalidRequest(c, "Invalid created_after: "+err.Error())
+			return
+		}
+		if query.CreatedBefore, err = timeQuery(c, "created_before"); err != nil {
+			respondInvalidRequest(c, "Invalid created_before: "+err.Error())
+			return
+		}
+
+		page, err := linkService.ListLinks(query)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		links := make([]LinkResponse, 0, len(page.Links))
+		for i := range page.Links {
+			links = append(links, newLinkResponse(&page.Links[i]))
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"links":  links,
+			"total":  page.Total,
+			"limit":  page.Limit,
+			"offset": page.Offset,
+		})
+	}
+}
+
+// UpdateLinkRequest représente le corps JSON d'une modification partielle de lien.
+// Les champs absents sont laissés inchangés ; "headers" remplace l'ensemble des en-têtes du lien.
+type UpdateLinkRequest struct {
+	LongURL        *string            `json:"long_url" binding:"omitempty,url"`
+	RedirectStatus *int               `json:"redirect_status"`
+	Headers        *map[string]string `json:"headers"`
+	ForwardQuery   *bool              `json:"forward_query"`
+	QueryConflict  *string            `json:"query_conflict"`
+	ForwardPath    *bool              `json:"forward_path"`
+	Password       *string            `json:"password"` // Une chaîne vide retire la protection
+	// Programmation. Une plage "availability" vide ({}) retire la plage, une "fallback_url" vide retire l'URL de repli.
+	ActivateAt   *time.Time                 `json:"activate_at"`
+	Availability *models.AvailabilityWindow `json:"availability"`
+	FallbackURL  *string                    `json:"fallback_url" binding:"omitempty,url"`
+	Disabled     *bool                      `json:"disabled"`
+	// Destinations d'un test A/B. Une liste vide retire les variantes.
+	Variants       *[]models.LinkVariant `json:"variants"`
+	StickyVariants *bool                 `json:"sticky_variants"`
+	// Règles de ciblage par appareil. Une liste vide les retire.
+	Targeting *[]models.TargetingRule `json:"targeting"`
+	// Destinations par langue. Une map vide les retire.
+	Localized *map[string]string `json:"localized"`
+	// Destinations par plage horaire. Une liste vide les retire.
+	TimeRules *[]models.TimeRule `json:"time_rules"`
+	// Règles de redirection. Une liste vide les retire.
+	Rules *[]models.RedirectRule `json:"rules"`
+}
+
+// UpdateLinkHandler gère la modification de la destination et des réglages de redirection d'un lien.
+func UpdateLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		var req UpdateLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondInvalidRequest(c, "Invalid request data: "+err.Error())
+			return
+		}
+
+		link, err := linkService.UpdateLink(shortCode, services.LinkUpdate{
+			LongURL:        req.LongURL,
+			RedirectStatus: req.RedirectStatus,
+			Headers:        req.Headers,
+			ForwardQuery:   req.ForwardQuery,
+			QueryConflict:  req.QueryConflict,
+			ForwardPath:    req.ForwardPath,
+			Password:       req.Password,
+			ActivateAt:     req.ActivateAt,
+			Availability:   req.Availability,
+			FallbackURL:    req.FallbackURL,
+			Disabled:       req.Disabled,
+			Variants:       req.Variants,
+			StickyVariants: req.StickyVariants,
+			Targeting:      req.Targeting,
+			Localized:      req.Localized,
+			TimeRules:      req.TimeRules,
+			RedirectRules:  req.Rules,
+		})
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, newLinkResponse(link))
+	}
+}
+
+// DeleteLinkHandler gère la suppression d'un lien et de ses clics.
+func DeleteLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		if err := linkService.DeleteLink(shortCode); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// intQuery lit un paramètre de requête entier optionnel (0 s'il est absent).
+func intQuery(c *gin.Context, key string) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// timeQuery lit un paramètre de requête optionnel au format RFC 3339.
+func timeQuery(c *gin.Context, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
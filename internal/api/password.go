@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// accessCookieName est le nom du cookie portant le jeton d'accès à un lien protégé.
+// Son chemin est limité au code court : chaque lien a son propre cookie.
+const accessCookieName = "link_access"
+
+// passwordPromptTemplate est la page de saisie du mot de passe d'un lien protégé.
+// Le formulaire est renvoyé sur l'URL visitée, query string et chemin compris.
+var passwordPromptTemplate = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Lien protégé</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #f4f4f5; display: flex; justify-content: center; padding-top: 15vh; margin: 0; }
+main { background: #fff; padding: 2rem; border-radius: 8px; box-shadow: 0 1px 4px rgba(0,0,0,.1); width: 20rem; }
+h1 { font-size: 1.2rem; margin-top: 0; }
+input, button { width: 100%; box-sizing: border-box; padding: .6rem; margin-top: .6rem; font-size: 1rem; }
+.error { color: #b91c1c; }
+</style>
+</head>
+<body>
+<main>
+<h1>Ce lien est protégé par un mot de passe</h1>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+{{if not .Blocked}}
+<form method="post" action="{{.Action}}">
+<input type="password" name="password" placeholder="Mot de passe" autofocus required>
+<button type="submit">Continuer</button>
+</form>
+{{end}}
+</main>
+</body>
+</html>
+`))
+
+// passwordPrompt est le modèle de données de la page de saisie du mot de passe.
+type passwordPrompt struct {
+	Action  string
+	Error   string
+	Blocked bool
+}
+
+// renderPasswordPrompt affiche la page de saisie du mot de passe avec le statut donné.
+func renderPasswordPrompt(c *gin.Context, status int, prompt passwordPrompt) {
+	prompt.Action = c.Request.URL.RequestURI()
+	c.Header("Cache-Control", "no-store")
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := passwordPromptTemplate.Execute(c.Writer, prompt); err != nil {
+		log.Printf("Error rendering password prompt: %v", err)
+	}
+	c.Abort()
+}
+
+// accessToken renvoie le jeton d'accès présenté par le visiteur, vide s'il n'en a pas.
+func accessToken(c *gin.Context) string {
+	token, err := c.Cookie(accessCookieName)
+	if err != nil {
+		return ""
+	}
+	return token
+}
+
+// UnlockLinkHandler vérifie le mot de passe soumis pour un lien protégé. En cas de succès,
+// il dépose un cookie d'accès signé et de courte durée, puis renvoie le visiteur (303) sur
+// l'URL visitée : la redirection et l'enregistrement du clic se font alors normalement.
+func UnlockLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		access, err := linkService.UnlockLink(shortCode, c.PostForm("password"), c.ClientIP())
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrWrongPassword):
+				renderPasswordPrompt(c, http.StatusUnauthorized, passwordPrompt{Error: "Mot de passe incorrect."})
+			case errors.Is(err, services.ErrTooManyAttempts):
+				wait, _ := services.RetryDelay(err)
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				renderPasswordPrompt(c, http.StatusTooManyRequests, passwordPrompt{
+					Error:   fmt.Sprintf("Trop de tentatives échouées. Réessayez dans %d minute(s).", int(math.Ceil(wait.Minutes()))),
+					Blocked: true,
+				})
+			default:
+				respondError(c, err)
+			}
+			return
+		}
+
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(accessCookieName, access.Token, int(time.Until(access.ExpiresAt).Seconds()),
+			"/"+shortCode, "", strings.HasPrefix(cmd2.Cfg.Server.BaseURL, "https://"), true)
+		c.Redirect(http.StatusSeeOther, c.Request.URL.RequestURI())
+	}
+}
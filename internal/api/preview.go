@@ -0,0 +1,193 @@
+package api
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/monitor"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// previewSuffix, ajouté au code court ("/abc123+"), demande la page d'aperçu au lieu de la redirection.
+// Le paramètre "preview=1" a le même effet.
+const (
+	previewSuffix     = "+"
+	previewQueryParam = "preview"
+)
+
+// Statuts de santé de la destination exposés par l'aperçu.
+const (
+	healthUp      = "up"
+	healthDown    = "down"
+	healthUnknown = "unknown" // Pas encore vérifiée par le moniteur
+)
+
+// PreviewHealth est le dernier état connu de la destination d'un lien, relevé par le moniteur d'URLs.
+type PreviewHealth struct {
+	Status     string     `json:"status"` // up, down ou unknown
+	StatusCode int        `json:"status_code,omitempty"`
+	CheckedAt  *time.Time `json:"checked_at,omitempty"`
+}
+
+// PreviewResponse représente l'aperçu d'un lien : sa destination et des informations pour juger
+// de sa fiabilité avant de le suivre.
+type PreviewResponse struct {
+	ShortCode         string    `json:"short_code"`
+	FullShortURL      string    `json:"full_short_url"`
+	Destination       string    `json:"destination,omitempty"` // Absente lorsqu'elle est retenue
+	Title             string    `json:"title,omitempty"`       // Titre de la page de destination
+	CreatedAt         time.Time `json:"created_at"`
+	State             string    `json:"state"`
+	PasswordProtected bool      `json:"password_protected"`
+	SingleUse         bool      `json:"single_use"`
+	// DestinationWithheld indique que la destination n'est pas révélée : lien protégé par mot de passe,
+	// à usage unique ou qui n'est pas servi actuellement.
+	DestinationWithheld bool          `json:"destination_withheld"`
+	Health              PreviewHealth `json:"health"`
+}
+
+// newPreviewResponse assemble l'aperçu d'un lien et l'état de sa destination connu du moniteur.
+func newPreviewResponse(preview *services.LinkPreview, urlMonitor *monitor.UrlMonitor) PreviewResponse {
+	link := preview.Link
+	response := PreviewResponse{
+		ShortCode:           link.Shortcode,
+		FullShortURL:        cmd2.Cfg.Server.BaseURL + "/" + link.Shortcode,
+		Destination:         preview.Destination,
+		CreatedAt:           link.CreatedAt,
+		State:               preview.State,
+		PasswordProtected:   preview.PasswordProtected,
+		SingleUse:           link.SingleUse,
+		DestinationWithheld: preview.Withheld,
+		Health:              PreviewHealth{Status: healthUnknown},
+	}
+	if health, ok := urlMonitor.Health(link.ID); ok {
+		response.Health = PreviewHealth{Status: healthDown, StatusCode: health.StatusCode, CheckedAt: &health.CheckedAt}
+		if health.Accessible {
+			response.Health.Status = healthUp
+		}
+	}
+	// Le titre est celui de l'URL longue, relevé avec ses autres informations : il n'est pas montré
+	// quand la destination est retenue.
+	if link.Metadata != nil && !preview.Withheld {
+		response.Title = link.Metadata.Title
+	}
+	return response
+}
+
+// previewRequested indique si la visite demande l'aperçu du lien et renvoie le code court sans suffixe.
+func previewRequested(c *gin.Context) (string, bool) {
+	shortCode := c.Param("shortCode")
+	if code, found := strings.CutSuffix(shortCode, previewSuffix); found {
+		return code, true
+	}
+	return shortCode, c.Query(previewQueryParam) == "1"
+}
+
+// previewVisit décrit la visite à prévisualiser, sans le paramètre qui demande l'aperçu.
+func previewVisit(c *gin.Context) services.Visit {
+	visit := requestVisit(c)
+	visit.Query.Del(previewQueryParam)
+	return visit
+}
+
+// PreviewHandler renvoie l'aperçu JSON d'un lien. Aucun clic n'est enregistré.
+func PreviewHandler(linkService *services.LinkService, urlMonitor *monitor.UrlMonitor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		preview, err := linkService.PreviewLink(c.Param("shortCode"), previewVisit(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, newPreviewResponse(preview, urlMonitor))
+	}
+}
+
+// previewTemplate est la page d'aperçu affichée avant de suivre un lien court.
+var previewTemplate = template.Must(template.New("preview").Funcs(template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("02/01/2006 15:04 MST") },
+}).Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Aperçu du lien {{.ShortCode}}</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #f4f4f5; display: flex; justify-content: center; padding-top: 15vh; margin: 0; }
+main { background: #fff; padding: 2rem; border-radius: 8px; box-shadow: 0 1px 4px rgba(0,0,0,.1); width: 32rem; max-width: 90vw; }
+h1 { font-size: 1.2rem; margin-top: 0; }
+dt { color: #52525b; font-size: .85rem; margin-top: .8rem; }
+dd { margin: .2rem 0 0; overflow-wrap: anywhere; }
+.up { color: #15803d; }
+.down { color: #b91c1c; }
+.warning { color: #b45309; }
+a.button { display: inline-block; margin-top: 1.4rem; padding: .6rem 1.2rem; background: #2563eb; color: #fff; border-radius: 4px; text-decoration: none; }
+</style>
+</head>
+<body>
+<main>
+<h1>Aperçu du lien {{.FullShortURL}}</h1>
+<dl>
+{{if .PasswordProtected}}
+<dt>Destination</dt>
+<dd>Ce lien est protégé par un mot de passe : sa destination n'est pas affichée.</dd>
+{{else if .SingleUse}}
+<dt>Destination</dt>
+<dd>Ce lien est à usage unique : sa destination n'est révélée qu'au visiteur qui le suit.</dd>
+{{else if .DestinationWithheld}}
+<dt>Destination</dt>
+<dd>Ce lien n'est pas servi actuellement : sa destination n'est pas affichée.</dd>
+{{else}}
+<dt>Destination</dt>
+<dd>{{.Destination}}</dd>
+{{if .Title}}<dt>Titre de la page</dt>
+<dd>{{.Title}}</dd>{{end}}
+{{end}}
+<dt>Créé le</dt>
+<dd>{{date .CreatedAt}}</dd>
+<dt>État de la destination</dt>
+{{if eq .Health.Status "up"}}<dd class="up">Accessible (HTTP {{.Health.StatusCode}}), vérifiée le {{date .Health.CheckedAt}}</dd>
+{{else if eq .Health.Status "down"}}<dd class="down">Inaccessible{{if .Health.StatusCode}} (HTTP {{.Health.StatusCode}}){{end}}, vérifiée le {{date .Health.CheckedAt}}</dd>
+{{else}}<dd>Pas encore vérifiée</dd>{{end}}
+</dl>
+{{if ne .State "active"}}<p class="warning">Ce lien n'est pas servi actuellement (état : {{.State}}).</p>{{end}}
+<a class="button" href="{{.Target}}" rel="noreferrer">Continuer vers la destination</a>
+</main>
+</body>
+</html>
+`))
+
+// previewPage est le modèle de données de la page d'aperçu.
+type previewPage struct {
+	PreviewResponse
+	Target string // Lien court à suivre, sans la demande d'aperçu
+}
+
+// renderPreview affiche la page d'aperçu d'un lien. Aucun clic n'est enregistré : le bouton
+// "Continuer" renvoie vers le lien court, qui redirige normalement.
+func renderPreview(c *gin.Context, linkService *services.LinkService, urlMonitor *monitor.UrlMonitor, shortCode string) {
+	visit := previewVisit(c)
+	preview, err := linkService.PreviewLink(shortCode, visit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	target := "/" + shortCode + visit.Path
+	if len(visit.Query) > 0 {
+		target += "?" + visit.Query.Encode()
+	}
+	page := previewPage{PreviewResponse: newPreviewResponse(preview, urlMonitor), Target: target}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := previewTemplate.Execute(c.Writer, page); err != nil {
+		log.Printf("Error rendering preview page: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// qrMaxAge est la durée de cache navigateur des QR codes : l'URL courte d'un code ne change pas.
+const qrMaxAge = 24 * 60 * 60
+
+// QRCodeHandler renvoie le QR code de l'URL courte complète d'un lien, en PNG ou en SVG.
+// Paramètres de requête : format (png ou svg ; sans lui, PNG sauf si l'en-tête Accept préfère
+// image/svg+xml à image/png), size (pixels), margin (modules), level (L, M, Q ou H), fg et bg
+// (couleurs hexadécimales, ex: fg=1e3a8a).
+func QRCodeHandler(linkService *services.LinkService, qrGenerator *services.QRGenerator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts := services.DefaultQROptions()
+		if format := c.Query("format"); format != "" {
+			opts.Format = format
+		} else {
+			opts.Format = services.NegotiateQRFormat(c.GetHeader("Accept"))
+		}
+		var err error
+		if opts.Size, err = intQueryOr(c, "size", opts.Size); err != nil {
+			respondInvalidRequest(c, "Invalid size: "+err.Error())
+			return
+		}
+		if opts.Margin, err = intQueryOr(c, "margin", opts.Margin); err != nil {
+			respondInvalidRequest(c, "Invalid margin: "+err.Error())
+			return
+		}
+		opts.Level = c.DefaultQuery("level", opts.Level)
+		opts.Foreground = c.DefaultQuery("fg", opts.Foreground)
+		opts.Background = c.DefaultQuery("bg", opts.Background)
+
+		link, err := linkService.GetLinkByShortCode(c.Param("shortCode"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		image, err := qrGenerator.Generate(cmd2.Cfg.Server.BaseURL+"/"+link.Shortcode, opts)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", qrMaxAge))
+		// Sans paramètre format, l'image dépend de l'en-tête Accept : un cache partagé ne doit pas
+		// servir le PNG à un client qui demande du SVG, ni l'inverse.
+		c.Header("Vary", "Accept")
+		c.Header("ETag", image.ETag)
+		if c.GetHeader("If-None-Match") == image.ETag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		extension := services.QRFormatPNG
+		if image.ContentType == "image/svg+xml" {
+			extension = services.QRFormatSVG
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.%s"`, link.Shortcode, extension))
+		c.Data(http.StatusOK, image.ContentType, image.Data)
+	}
+}
+
+// intQueryOr lit un paramètre de requête entier, ou renvoie def s'il est absent.
+func intQueryOr(c *gin.Context, key string, def int) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return def, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// DryRunRequest décrit la visite fictive sur laquelle essayer les règles d'un lien.
+// Tous les champs sont optionnels ; l'instant par défaut est celui de la requête.
+type DryRunRequest struct {
+	Headers   map[string]string `json:"headers"`
+	Query     map[string]string `json:"query"`
+	Cookies   map[string]string `json:"cookies"`
+	IP        string            `json:"ip"`
+	Path      string            `json:"path"`
+	UserAgent string            `json:"user_agent"` // Raccourci pour l'en-tête User-Agent
+	Time      *time.Time        `json:"time"`
+}
+
+// visit construit la visite fictive correspondant à la requête d'essai.
+func (r DryRunRequest) visit() services.Visit {
+	header := make(http.Header, len(r.Headers))
+	for name, value := range r.Headers {
+		header.Set(name, value)
+	}
+	if r.UserAgent != "" {
+		header.Set("User-Agent", r.UserAgent)
+	}
+	query := make(url.Values, len(r.Query))
+	for name, value := range r.Query {
+		query.Set(name, value)
+	}
+	return services.Visit{
+		Path:           r.Path,
+		Query:          query,
+		UserAgent:      header.Get("User-Agent"),
+		AcceptLanguage: header.Get("Accept-Language"),
+		Header:         header,
+		Cookies:        r.Cookies,
+		ClientIP:       r.IP,
+	}
+}
+
+// DryRunRulesHandler évalue les règles d'un lien sur une visite fictive, sans redirection
+// ni enregistrement de clic, et renvoie la destination choisie avec la trace de chaque règle.
+func DryRunRulesHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req DryRunRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondInvalidRequest(c, "Invalid request data: "+err.Error())
+			return
+		}
+
+		at := time.Now()
+		if req.Time != nil {
+			at = *req.Time
+		}
+
+		result, err := linkService.DryRunRules(c.Param("shortCode"), req.visit(), at)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// requestCookies renvoie les cookies de la requête par nom. Pour un nom présent plusieurs fois,
+// la première valeur est retenue, comme le fait c.Cookie.
+func requestCookies(c *gin.Context) map[string]string {
+	cookies := c.Request.Cookies()
+	if len(cookies) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(cookies))
+	for _, cookie := range cookies {
+		if _, seen := values[cookie.Name]; !seen {
+			values[cookie.Name] = cookie.Value
+		}
+	}
+	return values
+}
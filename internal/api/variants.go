@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/gin-gonic/gin"
+)
+
+// variantCookieName est le nom du cookie qui retient la variante servie à un visiteur.
+// Son chemin est limité au code court : chaque lien a son propre cookie.
+const variantCookieName = "link_variant"
+
+// stickyVariant renvoie la variante déjà servie au visiteur, vide s'il n'en a pas.
+func stickyVariant(c *gin.Context) string {
+	variant, err := c.Cookie(variantCookieName)
+	if err != nil {
+		return ""
+	}
+	return variant
+}
+
+// setStickyVariant retient la variante servie au visiteur pour ses prochaines visites du lien.
+func setStickyVariant(c *gin.Context, shortCode, variant string) {
+	maxAge := cmd2.Cfg.ABTesting.StickyCookieDays * 24 * 60 * 60
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(variantCookieName, variant, maxAge, "/"+shortCode, "",
+		strings.HasPrefix(cmd2.Cfg.Server.BaseURL, "https://"), true)
+}
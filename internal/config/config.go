@@ -9,8 +9,9 @@ import (
 
 type Config struct {
 	Server struct {
-		Port     int    `mapstructure:"port"`
-		BaseURL  string `mapstructure:"base_url"`
+		Port           int      `mapstructure:"port"`
+		BaseURL        string   `mapstructure:"base_url"`
+		TrustedProxies []string `mapstructure:"trusted_proxies"`
 	} `mapstructure:"server"`
 
 	Database struct {
@@ -26,9 +27,75 @@ type Config struct {
 		IntervalMinutes int `mapstructure:"interval_minutes"`
 	} `mapstructure:"monitor"`
 
-	Workers struct {
-		ClickEventsBufferSize int `mapstructure:"click_events_buffer_size"`
-	} `mapstructure:"workers"`
+	ShortCode struct {
+		Strategy string `mapstructure:"strategy"`
+		Length   int    `mapstructure:"length"`
+		Alphabet string `mapstructure:"alphabet"`
+		Adaptive struct {
+			Enabled            bool    `mapstructure:"enabled"`
+			CollisionThreshold float64 `mapstructure:"collision_threshold"`
+			WindowSize         int     `mapstructure:"window_size"`
+			MaxLength          int     `mapstructure:"max_length"`
+		} `mapstructure:"adaptive"`
+	} `mapstructure:"shortcode"`
+
+	Canonicalization struct {
+		TrackingParams []string `mapstructure:"tracking_params"`
+	} `mapstructure:"canonicalization"`
+
+	Redirect struct {
+		StatusCode             int               `mapstructure:"status_code"`
+		Headers                map[string]string `mapstructure:"headers"`
+		PermanentMaxAgeSeconds int               `mapstructure:"permanent_max_age_seconds"`
+	} `mapstructure:"redirect"`
+
+	PasswordProtection struct {
+		CookieSecret         string `mapstructure:"cookie_secret"`
+		CookieTTLMinutes     int    `mapstructure:"cookie_ttl_minutes"`
+		MaxAttempts          int    `mapstructure:"max_attempts"`
+		AttemptWindowMinutes int    `mapstructure:"attempt_window_minutes"`
+	} `mapstructure:"password_protection"`
+
+	Scheduling struct {
+		UnavailableStatus int    `mapstructure:"unavailable_status"`
+		FallbackURL       string `mapstructure:"fallback_url"`
+	} `mapstructure:"scheduling"`
+
+	ABTesting struct {
+		StickyCookieDays int `mapstructure:"sticky_cookie_days"`
+	} `mapstructure:"ab_testing"`
+
+	ReservedWords struct {
+		Words              []string `mapstructure:"words"`
+		ProfanityFilter    bool     `mapstructure:"profanity_filter"`
+		Profanity          []string `mapstructure:"profanity"`
+		ProfanityAllowlist []string `mapstructure:"profanity_allowlist"`
+	} `mapstructure:"reserved_words"`
+
+	DestinationPolicy struct {
+		File      string `mapstructure:"file"`
+		HotReload bool   `mapstructure:"hot_reload"`
+	} `mapstructure:"destination_policy"`
+
+	Expiration struct {
+		SweepIntervalMinutes int `mapstructure:"sweep_interval_minutes"`
+	} `mapstructure:"expiration"`
+
+	Metadata struct {
+		Enabled             bool  `mapstructure:"enabled"`
+		WorkerCount         int   `mapstructure:"worker_count"`
+		QueueSize           int   `mapstructure:"queue_size"`
+		TimeoutSeconds      int   `mapstructure:"timeout_seconds"`
+		MaxBytes            int64 `mapstructure:"max_bytes"`
+		RefreshHours        int   `mapstructure:"refresh_hours"`
+		RetryMinutes        int   `mapstructure:"retry_minutes"`
+		ScanIntervalMinutes int   `mapstructure:"scan_interval_minutes"`
+	} `mapstructure:"metadata"`
+
+	QRCode struct {
+		CacheSize int `mapstructure:"cache_size"`
+		MaxSize   int `mapstructure:"max_size"`
+	} `mapstructure:"qr_code"`
 }
 
 // LoadConfig charge la configuration de l'application en utilisant Viper.
@@ -48,10 +115,45 @@ func LoadConfig() (*Config, error) {
 
 	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("server.base_url", "http://localhost:8080")
+	viper.SetDefault("server.trusted_proxies", []string{})
 	viper.SetDefault("database.name", "urlshortener.db")
 	viper.SetDefault("analytics.buffer_size", 1000)
 	viper.SetDefault("analytics.worker_count", 4)
 	viper.SetDefault("monitor.interval_minutes", 5)
+	viper.SetDefault("expiration.sweep_interval_minutes", 1)
+	viper.SetDefault("shortcode.strategy", "random")
+	viper.SetDefault("shortcode.length", 6)
+	viper.SetDefault("shortcode.alphabet", "base62")
+	viper.SetDefault("shortcode.adaptive.enabled", true)
+	viper.SetDefault("shortcode.adaptive.collision_threshold", 0.2)
+	viper.SetDefault("shortcode.adaptive.window_size", 100)
+	viper.SetDefault("shortcode.adaptive.max_length", 12)
+	viper.SetDefault("redirect.status_code", 302)
+	viper.SetDefault("redirect.permanent_max_age_seconds", 86400)
+	viper.SetDefault("password_protection.cookie_secret", "")
+	viper.SetDefault("password_protection.cookie_ttl_minutes", 10)
+	viper.SetDefault("password_protection.max_attempts", 5)
+	viper.SetDefault("password_protection.attempt_window_minutes", 15)
+	viper.SetDefault("scheduling.unavailable_status", 503)
+	viper.SetDefault("scheduling.fallback_url", "")
+	viper.SetDefault("ab_testing.sticky_cookie_days", 30)
+	viper.SetDefault("reserved_words.words", []string{})
+	viper.SetDefault("reserved_words.profanity_filter", true)
+	viper.SetDefault("reserved_words.profanity", []string{})
+	viper.SetDefault("reserved_words.profanity_allowlist", []string{})
+	viper.SetDefault("destination_policy.file", "configs/destination_policy.yaml")
+	viper.SetDefault("destination_policy.hot_reload", true)
+	viper.SetDefault("metadata.enabled", true)
+	viper.SetDefault("metadata.worker_count", 2)
+	viper.SetDefault("metadata.queue_size", 100)
+	viper.SetDefault("metadata.timeout_seconds", 5)
+	viper.SetDefault("metadata.max_bytes", 512*1024)
+	viper.SetDefault("metadata.refresh_hours", 7*24)
+	viper.SetDefault("metadata.retry_minutes", 60)
+	viper.SetDefault("metadata.scan_interval_minutes", 10)
+	viper.SetDefault("qr_code.cache_size", 256)
+	viper.SetDefault("qr_code.max_size", 2048)
+	viper.SetDefault("canonicalization.tracking_params", []string{"utm_*", "fbclid", "gclid", "dclid", "msclkid", "mc_cid", "mc_eid", "_ga", "igshid", "yclid"})
 
 
 	if err := viper.ReadInConfig(); err != nil {
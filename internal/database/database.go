@@ -0,0 +1,12 @@
+package database
+
+import (
+	"gorm.io/driver/sqlite" // Driver SQLite pour GORM
+	"gorm.io/gorm"
+)
+
+// Open ouvre la connexion GORM vers la base SQLite dont le nom de fichier est fourni.
+// C'est le point d'entrée unique utilisé par le serveur et par les commandes CLI.
+func Open(name string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Open(name), &gorm.Config{})
+}
@@ -0,0 +1,150 @@
+// Package metadata récupère les informations d'une page web (titre, description, image
+// Open Graph, icône) pour présenter les liens courts sous un nom lisible.
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"golang.org/x/net/html/charset"
+)
+
+// userAgent identifie les requêtes du service auprès des sites consultés.
+const userAgent = "url-shortener-metadata/1.0"
+
+// maxRedirects limite les redirections suivies avant d'atteindre la page.
+const maxRedirects = 5
+
+// ErrNotHTML est renvoyée lorsque la destination n'est pas une page HTML : elle n'a pas d'informations à relever.
+var ErrNotHTML = errors.New("destination is not an HTML page")
+
+// ErrForbiddenAddress est renvoyée lorsque la destination, ou l'une de ses redirections, désigne
+// une adresse interne (boucle locale, réseau privé, lien local) : le service ne la consulte pas.
+var ErrForbiddenAddress = errors.New("destination address is not public")
+
+// Fetcher télécharge le début des pages de destination et en extrait les informations.
+type Fetcher struct {
+	client   *http.Client
+	maxBytes int64                 // Partie de la page lue, les informations se trouvant dans l'en-tête <head>
+	allowed  func(netip.Addr) bool // Adresses que le Fetcher peut consulter
+}
+
+// NewFetcher crée un Fetcher dont chaque récupération dure au plus timeout et lit au plus maxBytes octets.
+// Seules les adresses publiques sont consultées, pour qu'un lien ne permette pas de lire une page interne.
+func NewFetcher(timeout time.Duration, maxBytes int64) *Fetcher {
+	return newFetcher(timeout, maxBytes, publicAddress)
+}
+
+// newFetcher crée un Fetcher qui ne se connecte qu'aux adresses acceptées par allowed.
+func newFetcher(timeout time.Duration, maxBytes int64, allowed func(netip.Addr) bool) *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		// L'adresse est contrôlée après la résolution DNS, juste avant la connexion : un nom
+		// public qui pointe vers une adresse interne est refusé lui aussi.
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !allowed(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	transport := &http.Transport{
+		Proxy:                 nil, // Un proxy ferait la connexion à notre place, sans contrôle de l'adresse
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	return &Fetcher{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				// via contient la requête initiale et les redirections déjà suivies.
+				if len(via) > maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				// Chaque étape est vérifiée : une page publique ne peut pas rediriger vers une adresse interne.
+				return checkTarget(req.URL, allowed)
+			},
+		},
+		maxBytes: maxBytes,
+		allowed:  allowed,
+	}
+}
+
+// publicAddress indique si une adresse peut être consultée : les adresses de boucle locale, privées,
+// de lien local, de multidiffusion et non spécifiées sont refusées.
+func publicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified()
+}
+
+// checkTarget refuse une URL qui n'est pas en HTTP(S) ou dont l'hôte est une adresse IP refusée.
+// Les noms d'hôte sont contrôlés à la connexion, une fois résolus.
+func checkTarget(u *url.URL, allowed func(netip.Addr) bool) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme '%s'", u.Scheme)
+	}
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil && !allowed(addr) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+	return nil
+}
+
+// Fetch récupère les informations de la page rawURL. Les URLs relatives (image, icône) sont
+// résolues par rapport à l'URL finale, après redirections.
+func (f *Fetcher) Fetch(rawURL string) (*models.PageMetadata, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTarget(req.URL, f.allowed); err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("destination answered with status %d", resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("%w (%s)", ErrNotHTML, mediaType)
+	}
+
+	// La page est convertie en UTF-8 d'après le Content-Type ou les balises <meta charset>.
+	body, err := charset.NewReader(io.LimitReader(resp.Body, f.maxBytes), contentType)
+	if err != nil {
+		return nil, err
+	}
+	metadata := Parse(body, resp.Request.URL)
+	return &metadata, nil
+}
@@ -0,0 +1,238 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loopbackOnly laisse les tests consulter les serveurs httptest, qui écoutent sur la boucle locale.
+func loopbackOnly(addr netip.Addr) bool {
+	return addr.Unmap().IsLoopback()
+}
+
+// newTestServer sert les pages de test :
+//   - /page/{nom} renvoie la page pages[nom] en text/html, ou avec le Content-Type contentTypes[nom] ;
+//   - /redirect/{n} redirige n fois avant d'atteindre /page/target ;
+//   - /slow répond après un délai supérieur au délai des récupérations.
+func newTestServer(t *testing.T, pages map[string]string, contentTypes map[string]string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		page, ok := pages[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		contentType := "text/html"
+		if ct, ok := contentTypes[name]; ok {
+			contentType = ct
+		}
+		w.Header().Set("Content-Type", contentType)
+		fmt.Fprint(w, page)
+	})
+	mux.HandleFunc("/redirect/{n}", func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(r.PathValue("n"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		target := "/page/target"
+		if n > 1 {
+			target = "/redirect/" + strconv.Itoa(n-1)
+		}
+		http.Redirect(w, r, target, http.StatusFound)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+		fmt.Fprint(w, "<title>Too late</title>")
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchMetadata(t *testing.T) {
+	pages := map[string]string{
+		"full": `<html><head>
+			<title>  Page
+			title </title>
+			<meta property="og:title" content="OG title">
+			<meta name="description" content="Page description">
+			<meta property="og:description" content="OG description">
+			<meta property="og:image" content="/images/cover.png">
+			<link rel="shortcut icon" href="static/icon.png">
+			</head><body><title>Body title</title></body></html>`,
+		"og-only": `<html><head>
+			<meta property="og:title" content="OG title">
+			<meta property="og:description" content="OG description">
+			<meta property="og:image:url" content="https://cdn.example.com/cover.png">
+			</head></html>`,
+		"unsafe-urls": `<head><title>Unsafe</title>
+			<meta property="og:image" content="javascript:alert(1)">
+			<link rel="icon" href="data:image/png;base64,AAAA"></head>`,
+		"latin1":   "<head><title>Caf\xe9 cr\xe8me</title></head>",
+		"meta1252": "<head><meta charset=\"windows-1252\"><title>\x93Quoted\x94 \x80</title></head>",
+		"target":   `<head><title>Target</title><link rel="icon" href="favicon.png"></head>`,
+	}
+	contentTypes := map[string]string{
+		"latin1": "text/html; charset=ISO-8859-1",
+	}
+	server := newTestServer(t, pages, contentTypes)
+	fetcher := newFetcher(time.Second, 64*1024, loopbackOnly)
+
+	tests := []struct {
+		name            string
+		path            string
+		wantTitle       string
+		wantDescription string
+		wantImage       string
+		wantFavicon     string
+	}{
+		{
+			name:            "title and description take precedence over Open Graph",
+			path:            "/page/full",
+			wantTitle:       "Page title",
+			wantDescription: "Page description",
+			wantImage:       server.URL + "/images/cover.png",
+			wantFavicon:     server.URL + "/page/static/icon.png",
+		},
+		{
+			name:            "Open Graph fallbacks",
+			path:            "/page/og-only",
+			wantTitle:       "OG title",
+			wantDescription: "OG description",
+			wantImage:       "https://cdn.example.com/cover.png",
+			wantFavicon:     server.URL + "/favicon.ico",
+		},
+		{
+			name:        "non-HTTP URLs are dropped",
+			path:        "/page/unsafe-urls",
+			wantTitle:   "Unsafe",
+			wantFavicon: server.URL + "/favicon.ico",
+		},
+		{
+			name:        "charset from the Content-Type header",
+			path:        "/page/latin1",
+			wantTitle:   "Café crème",
+			wantFavicon: server.URL + "/favicon.ico",
+		},
+		{
+			name:        "charset from a meta tag",
+			path:        "/page/meta1252",
+			wantTitle:   "“Quoted” €",
+			wantFavicon: server.URL + "/favicon.ico",
+		},
+		{
+			name:        "relative URLs resolve against the final URL",
+			path:        "/redirect/2",
+			wantTitle:   "Target",
+			wantFavicon: server.URL + "/page/favicon.png",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata, err := fetcher.Fetch(server.URL + tt.path)
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+			if metadata.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", metadata.Title, tt.wantTitle)
+			}
+			if metadata.Description != tt.wantDescription {
+				t.Errorf("Description = %q, want %q", metadata.Description, tt.wantDescription)
+			}
+			if metadata.Image != tt.wantImage {
+				t.Errorf("Image = %q, want %q", metadata.Image, tt.wantImage)
+			}
+			if metadata.Favicon != tt.wantFavicon {
+				t.Errorf("Favicon = %q, want %q", metadata.Favicon, tt.wantFavicon)
+			}
+		})
+	}
+}
+
+func TestFetchSizeLimit(t *testing.T) {
+	padding := "<!--" + strings.Repeat("x", 1000) + "-->"
+	server := newTestServer(t, map[string]string{
+		"early": "<head><title>Early</title>" + padding + "</head>",
+		"late":  "<head>" + padding + "<title>Late</title></head>",
+	}, nil)
+	fetcher := newFetcher(time.Second, 512, loopbackOnly)
+
+	for path, want := range map[string]string{"/page/early": "Early", "/page/late": ""} {
+		metadata, err := fetcher.Fetch(server.URL + path)
+		if err != nil {
+			t.Fatalf("Fetch %s: %v", path, err)
+		}
+		if metadata.Title != want {
+			t.Errorf("Fetch %s: Title = %q, want %q", path, metadata.Title, want)
+		}
+	}
+}
+
+func TestFetchTimeout(t *testing.T) {
+	server := newTestServer(t, nil, nil)
+	fetcher := newFetcher(200*time.Millisecond, 64*1024, loopbackOnly)
+
+	start := time.Now()
+	if _, err := fetcher.Fetch(server.URL + "/slow"); err == nil {
+		t.Fatal("Fetch succeeded, want a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Fetch returned after %v, want about 200ms", elapsed)
+	}
+}
+
+func TestFetchRedirectLimit(t *testing.T) {
+	server := newTestServer(t, map[string]string{"target": "<title>Target</title>"}, nil)
+	fetcher := newFetcher(time.Second, 64*1024, loopbackOnly)
+
+	if _, err := fetcher.Fetch(server.URL + "/redirect/" + strconv.Itoa(maxRedirects)); err != nil {
+		t.Errorf("%d redirects: %v, want success", maxRedirects, err)
+	}
+	if _, err := fetcher.Fetch(server.URL + "/redirect/" + strconv.Itoa(maxRedirects+1)); err == nil {
+		t.Errorf("%d redirects succeeded, want an error", maxRedirects+1)
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	server := newTestServer(t, map[string]string{"json": `{"title": "JSON"}`}, map[string]string{"json": "application/json"})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://10.0.0.1/admin", http.StatusFound)
+	})
+	internalRedirect := httptest.NewServer(mux)
+	t.Cleanup(internalRedirect.Close)
+
+	tests := []struct {
+		name    string
+		fetcher *Fetcher
+		url     string
+		want    error
+	}{
+		{"not HTML", newFetcher(time.Second, 1024, loopbackOnly), server.URL + "/page/json", ErrNotHTML},
+		{"loopback refused", NewFetcher(time.Second, 1024), server.URL + "/page/json", ErrForbiddenAddress},
+		{"loopback name refused", NewFetcher(time.Second, 1024), strings.Replace(server.URL, "127.0.0.1", "localhost", 1), ErrForbiddenAddress},
+		{"redirect to an internal address", newFetcher(time.Second, 1024, loopbackOnly), internalRedirect.URL, ErrForbiddenAddress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.fetcher.Fetch(tt.url)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Fetch error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package metadata
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"golang.org/x/net/html"
+)
+
+// Longueurs maximales des informations conservées, en caractères.
+const (
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+	maxURLLength         = 2048
+)
+
+// Parse extrait les informations de l'en-tête d'une page HTML. La lecture s'arrête au début du
+// corps de la page. base sert à résoudre les URLs relatives ; l'icône vaut /favicon.ico du site
+// lorsque la page n'en déclare pas.
+func Parse(r io.Reader, base *url.URL) models.PageMetadata {
+	var title, ogTitle, description, ogDescription, image, favicon string
+	tokenizer := html.NewTokenizer(r)
+
+scan:
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			break scan
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "body":
+				break scan
+			case "title":
+				if title == "" && tokenType == html.StartTagToken && tokenizer.Next() == html.TextToken {
+					title = string(tokenizer.Text())
+				}
+			case "meta":
+				attrs := attributes(tokenizer, hasAttr)
+				content := attrs["content"]
+				switch {
+				case strings.EqualFold(attrs["name"], "description"):
+					description = firstNonEmpty(description, content)
+				case strings.EqualFold(attrs["property"], "og:title"):
+					ogTitle = firstNonEmpty(ogTitle, content)
+				case strings.EqualFold(attrs["property"], "og:description"):
+					ogDescription = firstNonEmpty(ogDescription, content)
+				case strings.EqualFold(attrs["property"], "og:image"), strings.EqualFold(attrs["property"], "og:image:url"):
+					image = firstNonEmpty(image, content)
+				}
+			case "link":
+				attrs := attributes(tokenizer, hasAttr)
+				if favicon == "" && isIconRel(attrs["rel"]) {
+					favicon = attrs["href"]
+				}
+			}
+		}
+	}
+
+	metadata := models.PageMetadata{
+		Title:       clean(firstNonEmpty(title, ogTitle), maxTitleLength),
+		Description: clean(firstNonEmpty(description, ogDescription), maxDescriptionLength),
+		Image:       resolve(base, image),
+		Favicon:     resolve(base, favicon),
+	}
+	if metadata.Favicon == "" {
+		metadata.Favicon = resolve(base, "/favicon.ico")
+	}
+	return metadata
+}
+
+// attributes renvoie les attributs de la balise courante, noms en minuscules.
+func attributes(tokenizer *html.Tokenizer, hasAttr bool) map[string]string {
+	attrs := make(map[string]string)
+	for hasAttr {
+		var key, value []byte
+		key, value, hasAttr = tokenizer.TagAttr()
+		attrs[strings.ToLower(string(key))] = string(value)
+	}
+	return attrs
+}
+
+// isIconRel indique si l'attribut rel d'une balise <link> désigne l'icône du site ("icon", "shortcut icon").
+func isIconRel(rel string) bool {
+	for _, token := range strings.Fields(rel) {
+		if strings.EqualFold(token, "icon") {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve rend une URL absolue par rapport à base. Les URLs invalides, trop longues ou qui ne sont
+// pas en http(s) (data:, javascript:...) sont écartées.
+func resolve(base *url.URL, raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	resolved := base.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return ""
+	}
+	if s := resolved.String(); len(s) <= maxURLLength {
+		return s
+	}
+	return ""
+}
+
+// clean normalise les espaces d'un texte et le tronque à max caractères.
+func clean(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:max-1])) + "…"
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
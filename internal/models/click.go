@@ -11,6 +11,8 @@ type Click struct {
 	Timestamp time.Time // Horodatage précis du clic
 	UserAgent string    `gorm:"size:255"` // User-Agent de l'utilisateur qui a cliqué (informations sur le navigateur/OS)
 	IPAddress string    `gorm:"size:50"`  // Adresse IP de l'utilisateur
+	Variant   string    `gorm:"size:32;index"` // Variante servie pour un lien en test A/B, vide sinon
+	Language  string    `gorm:"size:35"`       // Langue négociée pour un lien localisé, vide si la destination par défaut a été servie
 }
 
 
@@ -19,4 +21,6 @@ type ClickEvent struct {
     Timestamp time.Time
     UserAgent string
     IPAddress string
+    Variant   string
+    Language  string
 }
\ No newline at end of file
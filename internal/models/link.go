@@ -2,12 +2,102 @@ package models
 
 import "time"
 
+// Link représente une URL raccourcie.
+// GORM utilisera ces tags pour créer la table 'links'.
+type Link struct {
+	ID         uint   `gorm:"primaryKey"`
+	Shortcode  string `gorm:"size:32;uniqueIndex;not null"`
+	LongURL    string `gorm:"not null;index"` // Indexé pour retrouver rapidement un lien existant vers la même destination
+	Domain     string `gorm:"size:255;index"` // Hôte de l'URL longue (en minuscules), utilisé pour filtrer les liens par domaine
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time // Date d'expiration optionnelle (UTC), nil si le lien n'expire jamais
+	MaxClicks  *int       // Budget de clics optionnel, nil si illimité
+	UsedClicks int        `gorm:"not null;default:0"`     // Nombre de redirections déjà servies, décompté du budget
+	ExpiredAt  *time.Time `gorm:"index"`                  // Renseigné par le balayeur lorsque le lien a expiré, ou à la consommation d'un lien à usage unique
+	SingleUse  bool       `gorm:"not null;default:false"` // Lien à usage unique, consommé par la première redirection
 
+	RedirectStatus  int               `gorm:"not null;default:0"` // 301, 302, 307 ou 308 ; 0 pour le statut global
+	ResponseHeaders map[string]string `gorm:"serializer:json"`    // En-têtes ajoutés à la redirection (Cache-Control...)
 
+	ForwardQuery  bool   `gorm:"not null;default:false"` // Ajoute les paramètres de la requête à la destination
+	QueryConflict string `gorm:"size:16"`                // Politique en cas de paramètre déjà présent : destination, request ou append
+	ForwardPath   bool   `gorm:"not null;default:false"` // Ajoute le chemin qui suit le code court à la destination
 
-type Link struct {
-    ID        uint      `gorm:"primaryKey"`                          
-    Shortcode string    `gorm:"size:10;uniqueIndex;not null"`        
-    LongURL   string    `gorm:"not null"`                           
-    CreatedAt time.Time
-}
\ No newline at end of file
+	PasswordHash string `gorm:"size:72"` // Empreinte bcrypt du mot de passe, vide si le lien n'est pas protégé
+
+	ActivateAt   *time.Time          `gorm:"index"`           // Date d'activation optionnelle (UTC) : le lien n'est pas servi avant
+	Availability *AvailabilityWindow `gorm:"serializer:json"` // Plage de disponibilité récurrente optionnelle
+	FallbackURL  string              // URL servie lorsque le lien n'est pas encore ou plus disponible dans sa plage
+	Disabled     bool                `gorm:"not null;default:false"` // Lien désactivé manuellement
+
+	Variants       []LinkVariant `gorm:"serializer:json"`        // Destinations pondérées d'un test A/B ; LongURL est alors la première
+	StickyVariants bool          `gorm:"not null;default:false"` // Sert toujours la même variante à un visiteur (cookie)
+
+	TargetingRules []TargetingRule `gorm:"serializer:json"` // Règles de ciblage par appareil, évaluées dans l'ordre avant la destination par défaut
+
+	Localized map[string]string `gorm:"serializer:json"` // Destinations par étiquette de langue (ex: "fr-CA"), négociées avec Accept-Language
+
+	TimeRules []TimeRule `gorm:"serializer:json"` // Destinations par plage horaire, évaluées dans l'ordre avant l'URL longue
+
+	RedirectRules []RedirectRule `gorm:"serializer:json"` // Règles "condition → destination", évaluées avant toutes les autres
+
+	Metadata          *PageMetadata `gorm:"serializer:json"` // Informations relevées sur la page de destination, nil avant la première récupération
+	MetadataNextFetch *time.Time    `gorm:"index"`           // Prochaine récupération des informations, nil pour une récupération au plus tôt
+}
+
+// PageMetadata regroupe les informations relevées sur la page de l'URL longue d'un lien,
+// pour présenter un nom lisible plutôt que l'URL brute.
+type PageMetadata struct {
+	Title       string    `json:"title,omitempty"`       // Balise <title>, ou og:title à défaut
+	Description string    `json:"description,omitempty"` // Meta description, ou og:description à défaut
+	Image       string    `json:"image,omitempty"`       // URL absolue de l'image Open Graph (og:image)
+	Favicon     string    `json:"favicon,omitempty"`     // URL absolue de l'icône déclarée, ou /favicon.ico du site
+	FetchedAt   time.Time `json:"fetched_at"`            // Dernière tentative de récupération
+	Error       string    `json:"error,omitempty"`       // Échec de la dernière tentative ; les informations précédentes sont conservées
+}
+
+// MetadataRequest demande la récupération des informations de la page de destination d'un lien.
+type MetadataRequest struct {
+	LinkID  uint
+	URL     string
+	Current *PageMetadata // Informations déjà connues, conservées si la récupération échoue
+}
+
+// RedirectRule redirige les visites qui satisfont sa condition vers une destination dédiée.
+// La condition est écrite dans le langage d'expressions du package rules.
+type RedirectRule struct {
+	Condition string `json:"condition"` // Ex: header("X-Team") == "beta" && ip_in(ip, "10.0.0.0/8")
+	URL       string `json:"url"`
+}
+
+// TimeRule redirige les visites reçues pendant une plage horaire récurrente vers une destination dédiée
+// (ex: le chat en direct aux heures ouvrées). La plage suit les règles d'AvailabilityWindow.
+type TimeRule struct {
+	AvailabilityWindow
+	URL string `json:"url"`
+}
+
+// TargetingRule redirige les visiteurs dont l'appareil correspond à tous ses critères vers une destination dédiée.
+// Un critère vide accepte toutes les valeurs ; une règle porte au moins un critère.
+type TargetingRule struct {
+	OS      string `json:"os,omitempty"`      // ios, android, windows, macos, linux, chromeos ou other
+	Device  string `json:"device,omitempty"`  // mobile, tablet, desktop ou bot
+	Browser string `json:"browser,omitempty"` // chrome, safari, firefox, edge, opera, samsung ou other
+	URL     string `json:"url"`
+}
+
+// LinkVariant est l'une des destinations pondérées d'un lien en test A/B.
+type LinkVariant struct {
+	Name   string `json:"name"`   // Nom de la variante, enregistré sur chaque clic
+	URL    string `json:"url"`    // Destination de la variante
+	Weight int    `json:"weight"` // Poids relatif dans le tirage
+}
+
+// AvailabilityWindow décrit une plage de disponibilité récurrente : le lien n'est servi que les jours
+// indiqués, entre Start et End, dans le fuseau horaire donné.
+type AvailabilityWindow struct {
+	Days     []string `json:"days,omitempty"`     // Jours concernés ("mon" à "sun"), tous les jours si vide
+	Start    string   `json:"start"`              // Heure de début "HH:MM"
+	End      string   `json:"end"`                // Heure de fin "HH:MM", exclue ; antérieure à Start pour une plage qui passe minuit
+	Timezone string   `json:"timezone,omitempty"` // Fuseau horaire IANA (ex: "Europe/Paris"), UTC si vide
+}
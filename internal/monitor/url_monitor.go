@@ -14,17 +14,24 @@ import (
 type UrlMonitor struct {
 	linkRepo    repository.LinkRepository // Pour récupérer les URLs à surveiller
 	interval    time.Duration             // Intervalle entre chaque vérification (ex: 5 minutes)
-	knownStates map[uint]bool             // État connu de chaque URL: map[LinkID]estAccessible (true/false)
+	knownStates map[uint]LinkHealth       // Dernier état connu de chaque URL: map[LinkID]LinkHealth
 	mu          sync.Mutex                // Mutex pour protéger l'accès concurrentiel à knownStates
 }
 
+// LinkHealth est le résultat de la dernière vérification de l'URL longue d'un lien.
+type LinkHealth struct {
+	Accessible bool      // Réponse 2xx ou 3xx
+	StatusCode int       // Statut HTTP reçu, 0 si la requête a échoué
+	CheckedAt  time.Time // Instant de la vérification
+}
+
 // NewUrlMonitor crée et retourne une nouvelle instance de UrlMonitor.
 // Attention: retourne un pointeur
 func NewUrlMonitor(linkRepo repository.LinkRepository, interval time.Duration) *UrlMonitor {
 	return &UrlMonitor{
         linkRepo:    linkRepo,
         interval:    interval,
-        knownStates: make(map[uint]bool),
+        knownStates: make(map[uint]LinkHealth),
     }
 }
 
@@ -49,20 +56,23 @@ func (m *UrlMonitor) checkUrls() {
 	log.Println("[MONITOR] Lancement de la vérification de l'état des URLs...")
 
 
-	links, err := m.linkRepo.GetAllLinks()
+	// Les liens expirés ne sont plus surveillés.
+	links, err := m.linkRepo.GetActiveLinks()
     if err != nil {
         log.Printf("[MONITOR] ERREUR lors de la récupération des liens pour la surveillance : %v", err)
         return
     }
 
 	for _, link := range links {
-		currentState := m.isUrlAccessible(link.LongURL)
+		health := m.checkUrl(link.LongURL)
+		currentState := health.Accessible
 
 		// Protéger l'accès à la map 'knownStates' car 'checkUrls' peut être exécuté concurremment
 		m.mu.Lock()
-		previousState, exists := m.knownStates[link.ID] // Récupère l'état précédent
-		m.knownStates[link.ID] = currentState           // Met à jour l'état actuel
+		previous, exists := m.knownStates[link.ID] // Récupère l'état précédent
+		m.knownStates[link.ID] = health            // Met à jour l'état actuel
 		m.mu.Unlock()
+		previousState := previous.Accessible
 
 		// Si c'est la première vérification pour ce lien, on initialise l'état sans notifier.
 		if !exists {
@@ -81,20 +91,35 @@ func (m *UrlMonitor) checkUrls() {
 	log.Println("[MONITOR] Vérification de l'état des URLs terminée.")
 }
 
-// isUrlAccessible effectue une requête HTTP HEAD pour vérifier l'accessibilité d'une URL.
-func (m *UrlMonitor) isUrlAccessible(url string) bool {
-    client := http.Client{
-        Timeout: 5 * time.Second,
-    }
+// Health renvoie le dernier état connu de l'URL longue d'un lien. Le booléen est faux
+// tant que le lien n'a pas encore été vérifié.
+func (m *UrlMonitor) Health(linkID uint) (LinkHealth, bool) {
+	if m == nil {
+		return LinkHealth{}, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	health, ok := m.knownStates[linkID]
+	return health, ok
+}
 
-    resp, err := client.Head(url)
-    if err != nil {
-        log.Printf("[MONITOR] Erreur d'accès à l'URL '%s': %v", url, err)
-        return false
-    }
-    defer resp.Body.Close()
+// checkUrl effectue une requête HTTP HEAD pour vérifier l'accessibilité d'une URL.
+func (m *UrlMonitor) checkUrl(url string) LinkHealth {
+	health := LinkHealth{CheckedAt: time.Now()}
+	client := http.Client{
+		Timeout: 5 * time.Second,
+	}
+
+	resp, err := client.Head(url)
+	if err != nil {
+		log.Printf("[MONITOR] Erreur d'accès à l'URL '%s': %v", url, err)
+		return health
+	}
+	defer resp.Body.Close()
 
-    return resp.StatusCode >= 200 && resp.StatusCode < 400
+	health.StatusCode = resp.StatusCode
+	health.Accessible = resp.StatusCode >= 200 && resp.StatusCode < 400
+	return health
 }
 
 // formatState est une fonction utilitaire pour rendre l'état plus lisible dans les logs.
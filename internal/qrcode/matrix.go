@@ -0,0 +1,277 @@
+package qrcode
+
+// matrix est la grille d'un QR code en construction. isFunction marque les modules des motifs
+// fixes, qui ne portent pas de données et ne sont pas masqués.
+type matrix struct {
+	version    int
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+func newMatrix(version int) *matrix {
+	size := version*4 + 17
+	m := &matrix{version: version, size: size, modules: make([][]bool, size), isFunction: make([][]bool, size)}
+	for y := 0; y < size; y++ {
+		m.modules[y] = make([]bool, size)
+		m.isFunction[y] = make([]bool, size)
+	}
+	return m
+}
+
+// setFunction place un module d'un motif fixe.
+func (m *matrix) setFunction(x, y int, dark bool) {
+	m.modules[y][x] = dark
+	m.isFunction[y][x] = true
+}
+
+// drawFunctionPatterns place les motifs de repérage, de synchronisation et d'alignement, ainsi que
+// les informations de version. Les informations de format sont réservées, puis écrites avec le masque.
+func (m *matrix) drawFunctionPatterns(level Level) {
+	for i := 0; i < m.size; i++ {
+		m.setFunction(6, i, i%2 == 0)
+		m.setFunction(i, 6, i%2 == 0)
+	}
+
+	m.drawFinderPattern(3, 3)
+	m.drawFinderPattern(m.size-4, 3)
+	m.drawFinderPattern(3, m.size-4)
+
+	positions := alignmentPositions(m.version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// Les trois coins occupés par les motifs de repérage n'ont pas de motif d'alignement.
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			m.drawAlignmentPattern(x, y)
+		}
+	}
+
+	m.drawFormatBits(level, 0)
+	m.drawVersion()
+}
+
+// drawFinderPattern place un motif de repérage centré en (x, y) et son séparateur clair.
+func (m *matrix) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= m.size || yy < 0 || yy >= m.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			m.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawAlignmentPattern place un motif d'alignement 5×5 centré en (x, y).
+func (m *matrix) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			m.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits écrit les deux copies des informations de format (niveau et masque),
+// protégées par un code BCH(15,5), ainsi que le module sombre fixe.
+func (m *matrix) drawFormatBits(level Level, mask int) {
+	bits := formatBits(level, mask)
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	// Première copie, autour du motif de repérage en haut à gauche.
+	for i := 0; i <= 5; i++ {
+		m.setFunction(8, i, bit(i))
+	}
+	m.setFunction(8, 7, bit(6))
+	m.setFunction(8, 8, bit(7))
+	m.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.setFunction(14-i, 8, bit(i))
+	}
+
+	// Seconde copie, répartie sous le motif en haut à droite et à droite du motif en bas à gauche.
+	for i := 0; i < 8; i++ {
+		m.setFunction(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.setFunction(8, m.size-15+i, bit(i))
+	}
+	m.setFunction(8, m.size-8, true)
+}
+
+// drawVersion écrit les deux copies des informations de version, à partir de la version 7,
+// protégées par un code BCH(18,6).
+func (m *matrix) drawVersion() {
+	if m.version < 7 {
+		return
+	}
+	bits := versionBits(m.version)
+	for i := 0; i < 18; i++ {
+		dark := (bits>>i)&1 == 1
+		a, b := m.size-11+i%3, i/3
+		m.setFunction(a, b, dark)
+		m.setFunction(b, a, dark)
+	}
+}
+
+// formatBits renvoie les 15 bits d'informations de format : le niveau et le masque sur 5 bits,
+// suivis de leur reste BCH, le tout masqué par 0x5412.
+func formatBits(level Level, mask int) int {
+	data := level.formatBits()<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// versionBits renvoie les 18 bits d'informations de version : la version sur 6 bits,
+// suivie de son reste BCH.
+func versionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
+
+// drawCodewords place les mots de code en zigzag, par colonnes de deux modules, de droite à gauche,
+// en alternant montée et descente et en sautant les motifs fixes.
+func (m *matrix) drawCodewords(codewords []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // La colonne du motif de synchronisation vertical est sautée
+		}
+		for vert := 0; vert < m.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = m.size - 1 - vert // Montée
+				}
+				if !m.isFunction[y][x] && i < len(codewords)*8 {
+					m.modules[y][x] = (codewords[i>>3]>>(7-(i&7)))&1 == 1
+					i++
+				}
+				// Les modules restants (bits de reste) restent clairs avant masquage.
+			}
+		}
+	}
+}
+
+// applyMask inverse les modules de données désignés par le motif de masque donné (0 à 7).
+func (m *matrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.isFunction[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				m.modules[y][x] = !m.modules[y][x]
+			}
+		}
+	}
+}
+
+// Pondérations des règles de pénalité servant à choisir le masque.
+const (
+	penaltyN1 = 3
+	penaltyN2 = 3
+	penaltyN3 = 40
+	penaltyN4 = 10
+)
+
+// finderLike sont les suites de modules qui ressemblent à un motif de repérage (règle N3).
+var finderLike = [2][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// penalty évalue un symbole masqué : longues suites de même couleur, blocs 2×2 uniformes,
+// motifs trompeurs et déséquilibre entre modules sombres et clairs.
+func (m *matrix) penalty() int {
+	result := 0
+	dark := 0
+	for i := 0; i < m.size; i++ {
+		result += m.linePenalty(func(j int) bool { return m.modules[i][j] }) // Ligne i
+		result += m.linePenalty(func(j int) bool { return m.modules[j][i] }) // Colonne i
+	}
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.modules[y][x] {
+				dark++
+			}
+			if x+1 < m.size && y+1 < m.size {
+				c := m.modules[y][x]
+				if c == m.modules[y][x+1] && c == m.modules[y+1][x] && c == m.modules[y+1][x+1] {
+					result += penaltyN2
+				}
+			}
+		}
+	}
+	total := m.size * m.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return result + k*penaltyN4
+}
+
+// linePenalty évalue les règles N1 et N3 sur une ligne ou une colonne, lue par at.
+func (m *matrix) linePenalty(at func(int) bool) int {
+	result := 0
+	run := 1
+	for j := 1; j <= m.size; j++ {
+		if j < m.size && at(j) == at(j-1) {
+			run++
+			continue
+		}
+		if run >= 5 {
+			result += penaltyN1 + run - 5
+		}
+		run = 1
+	}
+	for j := 0; j+11 <= m.size; j++ {
+		for _, pattern := range finderLike {
+			match := true
+			for k, dark := range pattern {
+				if at(j+k) != dark {
+					match = false
+					break
+				}
+			}
+			if match {
+				result += penaltyN3
+			}
+		}
+	}
+	return result
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,194 @@
+// Package qrcode encode des données en QR code (ISO/IEC 18004) et rend le symbole en PNG ou en SVG.
+// L'encodeur est autonome : il utilise le mode octet, choisit la plus petite version (1 à 40)
+// qui contient les données au niveau de correction demandé, puis le masque de moindre pénalité.
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Level est le niveau de correction d'erreurs d'un QR code.
+type Level int
+
+// Niveaux de correction : proportion approximative du symbole qui peut être endommagée
+// sans empêcher la lecture.
+const (
+	LevelL Level = iota // ~7 %
+	LevelM              // ~15 %
+	LevelQ              // ~25 %
+	LevelH              // ~30 %
+)
+
+// formatBits sont les deux bits qui codent le niveau dans les informations de format.
+func (l Level) formatBits() int {
+	return [...]int{1, 0, 3, 2}[l]
+}
+
+// String renvoie la lettre du niveau (L, M, Q ou H).
+func (l Level) String() string {
+	return [...]string{"L", "M", "Q", "H"}[l]
+}
+
+// ParseLevel lit un niveau de correction donné par sa lettre, sans tenir compte de la casse.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "L":
+		return LevelL, nil
+	case "M":
+		return LevelM, nil
+	case "Q":
+		return LevelQ, nil
+	case "H":
+		return LevelH, nil
+	}
+	return 0, fmt.Errorf("unknown error correction level '%s', use L, M, Q or H", s)
+}
+
+// ErrDataTooLong est renvoyée lorsque les données ne tiennent pas dans un QR code de version 40
+// au niveau de correction demandé.
+var ErrDataTooLong = errors.New("data too long for a QR code")
+
+// Code est un QR code encodé : une grille carrée de modules sombres ou clairs, sans marge.
+type Code struct {
+	Version int
+	Level   Level
+	Size    int // Nombre de modules par côté (17 + 4 × version)
+	modules [][]bool
+}
+
+// Dark indique si le module de la colonne x et de la ligne y est sombre.
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// Encode encode des données en QR code au niveau de correction donné.
+func Encode(data []byte, level Level) (*Code, error) {
+	if level < LevelL || level > LevelH {
+		return nil, fmt.Errorf("invalid error correction level %d", level)
+	}
+
+	version := 0
+	for v := minVersion; v <= maxVersion; v++ {
+		if segmentBits(v, len(data)) <= numDataCodewords(v, level)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("%w: %d bytes at level %s", ErrDataTooLong, len(data), level)
+	}
+
+	codewords := addErrorCorrection(encodeData(data, version, level), version, level)
+	m := newMatrix(version)
+	m.drawFunctionPatterns(level)
+	m.drawCodewords(codewords)
+
+	// Le masque retenu est celui dont le symbole obtient la plus faible pénalité.
+	bestMask, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		m.applyMask(mask)
+		m.drawFormatBits(level, mask)
+		if penalty := m.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			bestMask, bestPenalty = mask, penalty
+		}
+		m.applyMask(mask) // Le masque est son propre inverse
+	}
+	m.applyMask(bestMask)
+	m.drawFormatBits(level, bestMask)
+
+	return &Code{Version: version, Level: level, Size: m.size, modules: m.modules}, nil
+}
+
+// segmentBits renvoie le nombre de bits d'un segment en mode octet de n octets pour une version.
+func segmentBits(version, n int) int {
+	return 4 + charCountBits(version) + 8*n
+}
+
+// charCountBits renvoie la taille du compteur de caractères du mode octet.
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// encodeData construit les mots de code de données : indicateur de mode, compteur, données,
+// terminateur puis octets de remplissage jusqu'à la capacité de la version.
+func encodeData(data []byte, version int, level Level) []byte {
+	capacity := numDataCodewords(version, level) * 8
+	var bb bitBuffer
+	bb.append(0x4, 4) // Mode octet
+	bb.append(len(data), charCountBits(version))
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+	bb.append(0, min(4, capacity-bb.len()))
+	bb.append(0, (8-bb.len()%8)%8)
+	for pad := 0xEC; bb.len() < capacity; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+	return bb.bytes()
+}
+
+// addErrorCorrection découpe les données en blocs, calcule les mots de correction de chaque bloc
+// et entrelace le tout dans l'ordre de placement dans le symbole.
+func addErrorCorrection(data []byte, version int, level Level) []byte {
+	numBlocks := numErrorCorrectionBlocks[level][version]
+	eccLen := eccCodewordsPerBlock[level][version]
+	rawCodewords := numRawDataModules(version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		dataLen := shortBlockLen - eccLen
+		if i >= numShortBlocks {
+			dataLen++
+		}
+		block := make([]byte, 0, shortBlockLen+1)
+		block = append(block, data[k:k+dataLen]...)
+		k += dataLen
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < numShortBlocks {
+			block = append(block, 0) // Les blocs courts ont un octet de données de moins
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			// L'octet ajouté aux blocs courts n'est pas transmis.
+			if i != shortBlockLen-eccLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// bitBuffer accumule des bits, du plus significatif au moins significatif.
+type bitBuffer struct {
+	bits []bool
+}
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		b.bits = append(b.bits, (value>>i)&1 == 1)
+	}
+}
+
+func (b *bitBuffer) len() int { return len(b.bits) }
+
+func (b *bitBuffer) bytes() []byte {
+	result := make([]byte, len(b.bits)/8)
+	for i, bit := range b.bits {
+		if bit {
+			result[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return result
+}
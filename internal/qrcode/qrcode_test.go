@@ -0,0 +1,235 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomonRemainder(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		// Bloc 1-M du tutoriel Thonky, « HELLO WORLD » en mode alphanumérique.
+		{
+			"HELLO WORLD 1-M",
+			[]byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17},
+			[]byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23},
+		},
+		// Exemple de l'annexe I de l'ISO/IEC 18004, « 01234567 » en mode numérique.
+		{
+			"01234567 1-M",
+			[]byte{16, 32, 12, 86, 97, 128, 236, 17, 236, 17, 236, 17, 236, 17, 236, 17},
+			[]byte{165, 36, 212, 193, 237, 54, 199, 135, 44, 85},
+		},
+	}
+	divisor := reedSolomonDivisor(10)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reedSolomonRemainder(tt.data, divisor); !bytes.Equal(got, tt.want) {
+				t.Errorf("ECC = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	// Table C.1 de l'ISO/IEC 18004, masques 0 à 7 pour chaque niveau.
+	want := map[Level][8]string{
+		LevelL: {"111011111000100", "111001011110011", "111110110101010", "111100010011101", "110011000101111", "110001100011000", "110110001000001", "110100101110110"},
+		LevelM: {"101010000010010", "101000100100101", "101111001111100", "101101101001011", "100010111111001", "100000011001110", "100111110010111", "100101010100000"},
+		LevelQ: {"011010101011111", "011000001101000", "011111100110001", "011101000000110", "010010010110100", "010000110000011", "010111011011010", "010101111101101"},
+		LevelH: {"001011010001001", "001001110111110", "001110011100111", "001100111010000", "000011101100010", "000001001010101", "000110100001100", "000100000111011"},
+	}
+	for level, masks := range want {
+		for mask, bits := range masks {
+			if got := fmt.Sprintf("%015b", formatBits(level, mask)); got != bits {
+				t.Errorf("formatBits(%s, %d) = %s, want %s", level, mask, got, bits)
+			}
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	// Table D.1 de l'ISO/IEC 18004.
+	want := map[int]int{
+		7: 0x07C94, 8: 0x085BC, 9: 0x09A99, 10: 0x0A4D3, 11: 0x0BBF6, 12: 0x0C762, 13: 0x0D847,
+		14: 0x0E60D, 15: 0x0F928, 16: 0x10B78, 20: 0x149A6, 21: 0x15683, 25: 0x191E1,
+		30: 0x1ED75, 32: 0x209D5, 40: 0x28C69,
+	}
+	for version, bits := range want {
+		if got := versionBits(version); got != bits {
+			t.Errorf("versionBits(%d) = %#05x, want %#05x", version, got, bits)
+		}
+	}
+}
+
+func TestEncodeCapacity(t *testing.T) {
+	// Capacité en octets de chaque niveau L, M, Q et H (table 7 de l'ISO/IEC 18004).
+	capacities := map[int][4]int{
+		1:  {17, 14, 11, 7},
+		2:  {32, 26, 20, 14},
+		3:  {53, 42, 32, 24},
+		4:  {78, 62, 46, 34},
+		5:  {106, 84, 60, 44},
+		10: {271, 213, 151, 119},
+		40: {2953, 2331, 1663, 1273},
+	}
+	for version, perLevel := range capacities {
+		for level := LevelL; level <= LevelH; level++ {
+			n := perLevel[level]
+			code, err := Encode(bytes.Repeat([]byte("a"), n), level)
+			if err != nil {
+				t.Fatalf("Encode(%d bytes, %s): %v", n, level, err)
+			}
+			if code.Version != version || code.Size != 17+4*version {
+				t.Errorf("Encode(%d bytes, %s) = version %d, size %d, want version %d", n, level, code.Version, code.Size, version)
+			}
+			next, err := Encode(bytes.Repeat([]byte("a"), n+1), level)
+			if version == maxVersion {
+				if !errors.Is(err, ErrDataTooLong) {
+					t.Errorf("Encode(%d bytes, %s) error = %v, want ErrDataTooLong", n+1, level, err)
+				}
+			} else if err != nil || next.Version != version+1 {
+				t.Errorf("Encode(%d bytes, %s) = %v, %v, want version %d", n+1, level, next, err, version+1)
+			}
+		}
+	}
+}
+
+func TestEncodeReferenceSymbol(t *testing.T) {
+	// Symbole 1-M de « hello world » en mode octet, masque 2, produit par un encodeur indépendant
+	// (github.com/skip2/go-qrcode).
+	want := []string{
+		"#######..#.##.#######",
+		"#.....#...#...#.....#",
+		"#.###.#.####..#.###.#",
+		"#.###.#.###.#.#.###.#",
+		"#.###.#.#.#.#.#.###.#",
+		"#.....#.#..#..#.....#",
+		"#######.#.#.#.#######",
+		"........#.#..........",
+		"#.#####..#.#..#####..",
+		".##.##.#.#.########.#",
+		"#.#.####.##.###..###.",
+		"#.#..#...#.###..###..",
+		"...#.#####..###.....#",
+		"........#.#.#...##..#",
+		"#######....#..#...##.",
+		"#.....#.#....#.#.####",
+		"#.###.#.#..#..##....#",
+		"#.###.#.##..######...",
+		"#.###.#.##..#..#..#..",
+		"#.....#..##.##..###..",
+		"#######.##.##.#.#..#.",
+	}
+	code, err := Encode([]byte("hello world"), LevelM)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if code.Version != 1 || code.Size != len(want) {
+		t.Fatalf("Encode = version %d, size %d, want version 1, size %d", code.Version, code.Size, len(want))
+	}
+	for y, row := range want {
+		if got := symbolRow(code, y); got != row {
+			t.Errorf("row %2d = %s\n        want %s", y, got, row)
+		}
+	}
+}
+
+func TestEncodeFunctionInformation(t *testing.T) {
+	for _, tt := range []struct {
+		size  int
+		level Level
+	}{{150, LevelL}, {700, LevelM}, {1200, LevelH}} {
+		code, err := Encode(bytes.Repeat([]byte("x"), tt.size), tt.level)
+		if err != nil {
+			t.Fatalf("Encode(%d bytes): %v", tt.size, err)
+		}
+		name := fmt.Sprintf("version %d-%s", code.Version, tt.level)
+
+		// Les deux copies des informations de version, en bas à gauche et en haut à droite.
+		var bottomLeft, topRight int
+		for i := 17; i >= 0; i-- {
+			a, b := code.Size-11+i%3, i/3
+			bottomLeft = bottomLeft<<1 | bit(code.Dark(b, a))
+			topRight = topRight<<1 | bit(code.Dark(a, b))
+		}
+		if want := versionBits(code.Version); bottomLeft != want || topRight != want {
+			t.Errorf("%s: version information %#05x and %#05x, want %#05x", name, bottomLeft, topRight, want)
+		}
+
+		// Les deux copies des informations de format doivent désigner le niveau demandé.
+		var first, second int
+		for i := 14; i >= 0; i-- {
+			first = first<<1 | bit(code.Dark(formatModule(code.Size, i, 0)))
+			second = second<<1 | bit(code.Dark(formatModule(code.Size, i, 1)))
+		}
+		if first != second {
+			t.Errorf("%s: format information copies %015b and %015b differ", name, first, second)
+		}
+		found := false
+		for mask := 0; mask < 8; mask++ {
+			found = found || first == formatBits(tt.level, mask)
+		}
+		if !found {
+			t.Errorf("%s: format information %015b does not encode level %s", name, first, tt.level)
+		}
+		if !code.Dark(8, code.Size-8) {
+			t.Errorf("%s: dark module is light", name)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for input, want := range map[string]Level{"L": LevelL, "m": LevelM, " q ": LevelQ, "H": LevelH} {
+		if got, err := ParseLevel(input); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", input, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("X"); err == nil {
+		t.Error("ParseLevel(\"X\") succeeded, want an error")
+	}
+}
+
+// symbolRow renvoie une ligne du symbole, « # » pour un module sombre et « . » pour un module clair.
+func symbolRow(code *Code, y int) string {
+	var b strings.Builder
+	for x := 0; x < code.Size; x++ {
+		if code.Dark(x, y) {
+			b.WriteByte('#')
+		} else {
+			b.WriteByte('.')
+		}
+	}
+	return b.String()
+}
+
+// formatModule renvoie la position du bit i des informations de format dans la copie donnée.
+func formatModule(size, i, which int) (int, int) {
+	if which == 0 {
+		switch {
+		case i <= 5:
+			return 8, i
+		case i <= 8:
+			return [...]int{8, 8, 7}[i-6], [...]int{7, 8, 8}[i-6]
+		default:
+			return 14 - i, 8
+		}
+	}
+	if i < 8 {
+		return size - 1 - i, 8
+	}
+	return 8, size - 15 + i
+}
+
+func bit(dark bool) int {
+	if dark {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,46 @@
+package qrcode
+
+// reedSolomonDivisor renvoie le polynôme générateur de Reed-Solomon de degré donné, sur GF(2^8)
+// modulo x^8 + x^4 + x^3 + x^2 + 1. Les coefficients vont du plus haut degré au plus bas,
+// celui du terme de plus haut degré (toujours 1) étant omis.
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1 // Polynôme constant 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		// Multiplie le polynôme courant par (x - r^i).
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// reedSolomonRemainder renvoie les mots de correction des données : le reste de leur division
+// polynomiale par le générateur.
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= gfMultiply(coef, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplie deux éléments de GF(2^8) modulo 0x11D.
+func gfMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
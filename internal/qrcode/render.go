@@ -0,0 +1,111 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+)
+
+// RenderOptions décrit l'image d'un QR code.
+type RenderOptions struct {
+	Size       int // Côté de l'image en pixels, marge comprise
+	Margin     int // Zone de silence autour du symbole, en modules (4 recommandés)
+	Foreground color.NRGBA
+	Background color.NRGBA
+}
+
+// PNG rend le QR code en image PNG carrée de opts.Size pixels. Chaque module occupe un nombre
+// entier de pixels ; le reste de la place est ajouté à la marge, pour un symbole centré.
+func (c *Code) PNG(opts RenderOptions) ([]byte, error) {
+	total := c.Size + 2*opts.Margin
+	if opts.Size < total {
+		return nil, fmt.Errorf("size must be at least %d pixels for this code", total)
+	}
+	scale := opts.Size / total
+	offset := (opts.Size - scale*c.Size) / 2
+
+	// Image en palette à deux couleurs : plus compacte qu'une image RGBA.
+	img := image.NewPaletted(image.Rect(0, 0, opts.Size, opts.Size), color.Palette{opts.Background, opts.Foreground})
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.Dark(x, y) {
+				continue
+			}
+			for py := 0; py < scale; py++ {
+				row := img.Pix[(offset+y*scale+py)*img.Stride:]
+				for px := 0; px < scale; px++ {
+					row[offset+x*scale+px] = 1
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG rend le QR code en image vectorielle de opts.Size pixels de côté. Les modules sombres
+// contigus d'une même ligne sont regroupés dans un seul chemin.
+func (c *Code) SVG(opts RenderOptions) []byte {
+	total := c.Size + 2*opts.Margin
+	var path strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; {
+			if !c.Dark(x, y) {
+				x++
+				continue
+			}
+			run := 1
+			for x+run < c.Size && c.Dark(x+run, y) {
+				run++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", x+opts.Margin, y+opts.Margin, run, run)
+			x += run
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">
+<rect width="100%%" height="100%%" %s/>
+<path d="%s" %s/>
+</svg>
+`, opts.Size, opts.Size, total, total, svgFill(opts.Background), path.String(), svgFill(opts.Foreground))
+	return buf.Bytes()
+}
+
+// svgFill renvoie les attributs SVG de remplissage d'une couleur, opacité comprise.
+func svgFill(c color.NRGBA) string {
+	fill := fmt.Sprintf(`fill="#%02x%02x%02x"`, c.R, c.G, c.B)
+	if c.A != 0xff {
+		fill += fmt.Sprintf(` fill-opacity="%s"`, strconv.FormatFloat(float64(c.A)/0xff, 'f', 3, 64))
+	}
+	return fill
+}
+
+// ParseColor lit une couleur hexadécimale : "rgb", "rrggbb" ou "rrggbbaa", avec ou sans '#'.
+func ParseColor(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return color.NRGBA{}, fmt.Errorf("invalid color '%s', use #rgb, #rrggbb or #rrggbbaa", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color '%s', use #rgb, #rrggbb or #rrggbbaa", s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
@@ -11,6 +11,7 @@ import (
 type ClickRepository interface {
 	CreateClick(click *models.Click) error
 	CountClicksByLinkID(linkID uint) (int, error) // Utilisé par LinkService pour les stats
+	GetRecentClicksByLinkID(linkID uint, limit int) ([]models.Click, error)
 }
 
 // GormClickRepository est l'implémentation de l'interface ClickRepository utilisant GORM.
@@ -43,3 +44,15 @@ func (r *GormClickRepository) CountClicksByLinkID(linkID uint) (int, error) {
 
 	return int(count), nil // Convert the int64 count to an int
 }
+
+// GetRecentClicksByLinkID récupère les clics les plus récents d'un lien, du plus récent au plus ancien.
+func (r *GormClickRepository) GetRecentClicksByLinkID(linkID uint, limit int) ([]models.Click, error) {
+	var clicks []models.Click
+
+	result := r.db.Where("link_id = ?", linkID).Order("timestamp DESC").Limit(limit).Find(&clicks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return clicks, nil
+}
@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"errors"
 	"log"
+	"time"
 
 	"github.com/axellelanca/urlshortener/internal/models"
 	"gorm.io/gorm"
@@ -12,10 +14,43 @@ import (
 type LinkRepository interface {
 	CreateLink(link *models.Link) error
 	GetLinkByShortCode(shortCode string) (*models.Link, error)
+	FindReusableLink(longURL string) (*models.Link, error)
 	GetAllLinks() ([]models.Link, error)
+	GetActiveLinks() ([]models.Link, error)
+	GetAllShortCodes() ([]string, error)
 	CountClicksByLinkID(linkID uint) (int, error)
+	CountClicksByVariant(linkID uint) (map[string]int64, error)
+	ListLinks(filter LinkFilter) ([]models.Link, int64, error)
+	UpdateLink(link *models.Link) error
+	DeleteLink(linkID uint) error
+	NextLinkID() (uint, error)
+	CountLinksByCodeLength() (map[int]int64, error)
+	ConsumeClick(linkID uint) (bool, error)
+	MarkExpiredLinks(now time.Time) (int64, error)
+	FindLinksDueForMetadata(now time.Time, limit int) ([]models.Link, error)
+	UpdateMetadata(linkID uint, metadata *models.PageMetadata, nextFetch time.Time) error
 }
 
+// LinkFilter décrit les critères de pagination, de filtrage et de tri utilisés pour lister les liens.
+// Les champs laissés à leur valeur zéro ne filtrent pas.
+type LinkFilter struct {
+	Limit         int
+	Offset        int
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Domain        string // Domaine exact ou parent (ex: "example.com" inclut "www.example.com")
+	Search        string // Texte recherché dans le code court ou l'URL longue
+	SortBy        string // Colonne de tri, validée en amont par la couche service
+	SortDesc      bool
+}
+
+// ErrCodeConflict est renvoyée lorsqu'un lien ne peut pas être inséré parce que
+// son code court est déjà utilisé (violation de l'index unique).
+var ErrCodeConflict = errors.New("short code already exists")
+
+// ErrLinkNotWritten est renvoyée lorsqu'une insertion s'est terminée sans erreur mais sans écrire de ligne.
+var ErrLinkNotWritten = errors.New("link was not written")
+
 type GormLinkRepository struct {
 	db *gorm.DB
 }
@@ -27,12 +62,23 @@ func NewLinkRepository(db *gorm.DB) *GormLinkRepository {
 }
 
 // CreateLink insère un nouveau lien dans la base de données.
+// Il renvoie ErrCodeConflict si le code court est déjà pris : l'unicité est
+// garantie par l'index unique, ce qui rend la réservation du code atomique.
+// Une insertion qui n'a écrit aucune ligne est toujours signalée comme une erreur.
 func (r *GormLinkRepository) CreateLink(link *models.Link) error {
-	if err := r.db.Create(link).Error; err != nil {
-		log.Printf("Erreur lors de la création du lien: %v", err)
-	} else {
-		log.Printf("Lien créé avec succès: %s", link.Shortcode)
+	result := r.db.Create(link)
+	if result.Error != nil {
+		if r.isUniqueViolation(result.Error) {
+			return ErrCodeConflict
+		}
+		log.Printf("Erreur lors de la création du lien: %v", result.Error)
+		return result.Error
 	}
+	if result.RowsAffected != 1 || link.ID == 0 {
+		log.Printf("Erreur lors de la création du lien %s: aucune ligne insérée", link.Shortcode)
+		return ErrLinkNotWritten
+	}
+	log.Printf("Lien créé avec succès: %s", link.Shortcode)
 	return nil
 }
 
@@ -53,6 +99,26 @@ func (r *GormLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link,
 	return &link, nil 
 }
 
+// FindReusableLink recherche le lien le plus récent vers la destination donnée qui peut être
+// partagé par plusieurs demandes : encore actif, sans date d'expiration ni budget de clics,
+// et sans aucun réglage propre (redirection, transfert, mot de passe, programmation, variantes, ciblage, langues, règles horaires et de redirection).
+// Il renvoie gorm.ErrRecordNotFound si aucun lien ne convient.
+func (r *GormLinkRepository) FindReusableLink(longURL string) (*models.Link, error) {
+	var link models.Link
+	err := r.db.
+		Where("long_url = ? AND expired_at IS NULL AND expires_at IS NULL AND max_clicks IS NULL", longURL).
+		Where("single_use = ? AND disabled = ? AND forward_query = ? AND forward_path = ?", false, false, false, false).
+		Where("redirect_status = 0 AND response_headers IS NULL").
+		Where("(password_hash IS NULL OR password_hash = '') AND (fallback_url IS NULL OR fallback_url = '')").
+		Where("activate_at IS NULL AND availability IS NULL AND variants IS NULL AND targeting_rules IS NULL AND localized IS NULL AND time_rules IS NULL AND redirect_rules IS NULL").
+		Order("id DESC").
+		First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
 // GetAllLinks récupère tous les liens de la base de données.
 // Cette méthode est utilisée par le moniteur d'URLs.
 func (r *GormLinkRepository) GetAllLinks() ([]models.Link, error) {
@@ -64,6 +130,50 @@ func (r *GormLinkRepository) GetAllLinks() ([]models.Link, error) {
 	return links, nil
 }
 
+// GetActiveLinks récupère les liens qui n'ont pas été marqués comme expirés par le balayeur.
+func (r *GormLinkRepository) GetActiveLinks() ([]models.Link, error) {
+	var links []models.Link
+	if err := r.db.Where("expired_at IS NULL").Find(&links).Error; err != nil {
+		log.Printf("Erreur lors de la récupération des liens actifs: %v", err)
+		return nil, err
+	}
+	return links, nil
+}
+
+// CountClicksByVariant compte les clics d'un lien pour chaque variante servie.
+// Les clics enregistrés sans variante sont comptés sous la clé vide.
+func (r *GormLinkRepository) CountClicksByVariant(linkID uint) (map[string]int64, error) {
+	var rows []struct {
+		Variant string
+		Count   int64
+	}
+	err := r.db.Model(&models.Click{}).
+		Select("variant, COUNT(*) AS count").
+		Where("link_id = ?", linkID).
+		Group("variant").
+		Scan(&rows).Error
+	if err != nil {
+		log.Printf("Erreur lors du comptage des clics par variante pour le lien ID %d: %v", linkID, err)
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Variant] = row.Count
+	}
+	return counts, nil
+}
+
+// GetAllShortCodes récupère les codes courts de tous les liens, sans charger les liens complets.
+func (r *GormLinkRepository) GetAllShortCodes() ([]string, error) {
+	var codes []string
+	if err := r.db.Model(&models.Link{}).Pluck("shortcode", &codes).Error; err != nil {
+		log.Printf("Erreur lors de la récupération des codes courts: %v", err)
+		return nil, err
+	}
+	return codes, nil
+}
+
 // CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné.
 func (r *GormLinkRepository) CountClicksByLinkID(linkID uint) (int, error) {
 	var count int64 // GORM retourne un int64 pour les comptes
@@ -75,3 +185,191 @@ func (r *GormLinkRepository) CountClicksByLinkID(linkID uint) (int, error) {
 
 	return int(count), nil
 }
+
+// ListLinks renvoie une page de liens correspondant au filtre, ainsi que le nombre total
+// de liens correspondants (avant pagination).
+func (r *GormLinkRepository) ListLinks(filter LinkFilter) ([]models.Link, int64, error) {
+	query := r.db.Model(&models.Link{})
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", filter.CreatedAfter.UTC())
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", filter.CreatedBefore.UTC())
+	}
+	if filter.Domain != "" {
+		query = query.Where("domain = ? OR domain LIKE ?", filter.Domain, "%."+filter.Domain)
+	}
+	if filter.Search != "" {
+		pattern := "%" + filter.Search + "%"
+		query = query.Where("shortcode LIKE ? OR long_url LIKE ?", pattern, pattern)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Printf("Erreur lors du comptage des liens: %v", err)
+		return nil, 0, err
+	}
+
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = "id"
+	}
+	order := sortBy + " ASC"
+	if filter.SortDesc {
+		order = sortBy + " DESC"
+	}
+
+	var links []models.Link
+	if err := query.Order(order).Limit(filter.Limit).Offset(filter.Offset).Find(&links).Error; err != nil {
+		log.Printf("Erreur lors de la récupération des liens: %v", err)
+		return nil, 0, err
+	}
+	return links, total, nil
+}
+
+// UpdateLink enregistre les modifications apportées à un lien existant.
+func (r *GormLinkRepository) UpdateLink(link *models.Link) error {
+	if err := r.db.Save(link).Error; err != nil {
+		if r.isUniqueViolation(err) {
+			return ErrCodeConflict
+		}
+		log.Printf("Erreur lors de la mise à jour du lien %s: %v", link.Shortcode, err)
+		return err
+	}
+	log.Printf("Lien mis à jour avec succès: %s", link.Shortcode)
+	return nil
+}
+
+// DeleteLink supprime un lien ainsi que tous les clics qui lui sont rattachés.
+// Les deux suppressions sont effectuées dans une même transaction.
+func (r *GormLinkRepository) DeleteLink(linkID uint) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("link_id = ?", linkID).Delete(&models.Click{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Link{}, linkID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Erreur lors de la suppression du lien ID %d: %v", linkID, err)
+		return err
+	}
+	return nil
+}
+
+// NextLinkID renvoie l'ID que devrait recevoir le prochain lien inséré (plus grand ID + 1).
+// La valeur est indicative : deux créations concurrentes peuvent obtenir le même résultat.
+func (r *GormLinkRepository) NextLinkID() (uint, error) {
+	var maxID uint
+	if err := r.db.Model(&models.Link{}).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error; err != nil {
+		log.Printf("Erreur lors de la récupération du prochain ID de lien: %v", err)
+		return 0, err
+	}
+	return maxID + 1, nil
+}
+
+// CountLinksByCodeLength compte les liens existants pour chaque longueur de code court.
+func (r *GormLinkRepository) CountLinksByCodeLength() (map[int]int64, error) {
+	var rows []struct {
+		Length int
+		Count  int64
+	}
+	err := r.db.Model(&models.Link{}).
+		Select("LENGTH(shortcode) AS length, COUNT(*) AS count").
+		Group("LENGTH(shortcode)").
+		Scan(&rows).Error
+	if err != nil {
+		log.Printf("Erreur lors du comptage des liens par longueur de code: %v", err)
+		return nil, err
+	}
+
+	counts := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Length] = row.Count
+	}
+	return counts, nil
+}
+
+// ConsumeClick décompte une redirection du budget de clics du lien.
+// La mise à jour est conditionnelle et atomique : elle échoue (false) si le budget est déjà épuisé
+// ou si le lien, à usage unique, a déjà été consommé, ce qui évite qu'un lien soit servi plus de fois
+// que prévu sous des requêtes concurrentes. Un lien à usage unique est marqué expiré dans la même requête.
+func (r *GormLinkRepository) ConsumeClick(linkID uint) (bool, error) {
+	now := time.Now().UTC()
+	result := r.db.Model(&models.Link{}).
+		Where("id = ? AND expired_at IS NULL", linkID).
+		Where("max_clicks IS NULL OR used_clicks < max_clicks").
+		Where("single_use = ? OR used_clicks = 0", false).
+		UpdateColumns(map[string]interface{}{
+			"used_clicks": gorm.Expr("used_clicks + 1"),
+			"expired_at":  gorm.Expr("CASE WHEN single_use THEN ? ELSE expired_at END", now),
+		})
+	if result.Error != nil {
+		log.Printf("Erreur lors du décompte du clic pour le lien ID %d: %v", linkID, result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// MarkExpiredLinks marque comme expirés les liens dont la date d'expiration est passée
+// ou dont le budget de clics est épuisé. Elle renvoie le nombre de liens marqués.
+func (r *GormLinkRepository) MarkExpiredLinks(now time.Time) (int64, error) {
+	now = now.UTC()
+	result := r.db.Model(&models.Link{}).
+		Where("expired_at IS NULL").
+		Where("(expires_at IS NOT NULL AND expires_at <= ?) OR (max_clicks IS NOT NULL AND used_clicks >= max_clicks)", now).
+		UpdateColumn("expired_at", now)
+	if result.Error != nil {
+		log.Printf("Erreur lors du marquage des liens expirés: %v", result.Error)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// FindLinksDueForMetadata récupère les liens actifs dont les informations de destination sont à
+// (re)récupérer, ceux qui n'en ont jamais eu en premier. Au plus limit liens sont renvoyés.
+func (r *GormLinkRepository) FindLinksDueForMetadata(now time.Time, limit int) ([]models.Link, error) {
+	var links []models.Link
+	err := r.db.Where("expired_at IS NULL AND disabled = ?", false).
+		Where("metadata_next_fetch IS NULL OR metadata_next_fetch <= ?", now.UTC()).
+		Order("metadata_next_fetch IS NOT NULL, metadata_next_fetch").
+		Limit(limit).
+		Find(&links).Error
+	if err != nil {
+		log.Printf("Erreur lors de la recherche des liens à enrichir: %v", err)
+		return nil, err
+	}
+	return links, nil
+}
+
+// UpdateMetadata enregistre les informations de destination d'un lien et la date de leur prochaine
+// récupération. Seules ces deux colonnes sont modifiées.
+func (r *GormLinkRepository) UpdateMetadata(linkID uint, metadata *models.PageMetadata, nextFetch time.Time) error {
+	nextFetch = nextFetch.UTC()
+	err := r.db.Model(&models.Link{ID: linkID}).
+		Select("Metadata", "MetadataNextFetch").
+		Updates(&models.Link{Metadata: metadata, MetadataNextFetch: &nextFetch}).Error
+	if err != nil {
+		log.Printf("Erreur lors de l'enregistrement des informations du lien ID %d: %v", linkID, err)
+		return err
+	}
+	return nil
+}
+
+// isUniqueViolation indique si l'erreur renvoyée par la base correspond à une violation de contrainte d'unicité.
+// Le dialecte GORM sait traduire les erreurs natives du driver en gorm.ErrDuplicatedKey.
+func (r *GormLinkRepository) isUniqueViolation(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	if translator, ok := r.db.Dialector.(gorm.ErrorTranslator); ok {
+		return errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey)
+	}
+	return false
+}
@@ -0,0 +1,633 @@
+package rules
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limites qui bornent la taille et donc le coût d'évaluation d'une condition.
+const (
+	MaxConditionLength = 1000 // Longueur maximale d'une condition, en octets
+	maxDepth           = 32   // Imbrication maximale des parenthèses, négations et appels
+	maxListItems       = 100  // Nombre maximal d'éléments d'une liste littérale
+)
+
+// CompileError décrit une condition invalide : erreur de syntaxe, de type ou littéral incorrect.
+type CompileError struct {
+	Pos int // Position (en octets) dans la condition
+	Msg string
+}
+
+func (e *CompileError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+// Condition est une condition compilée, prête à être évaluée sur des visites.
+type Condition struct {
+	source   string
+	eval     func(*Request) bool
+	usesPath bool
+}
+
+// Compile analyse et vérifie une condition. Les types sont vérifiés à la compilation :
+// une condition compilée ne peut pas échouer à l'évaluation.
+func Compile(source string) (*Condition, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, &CompileError{Pos: 0, Msg: "condition is empty"}
+	}
+	if len(source) > MaxConditionLength {
+		return nil, &CompileError{Pos: MaxConditionLength, Msg: fmt.Sprintf("condition is longer than %d bytes", MaxConditionLength)}
+	}
+
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, &CompileError{Pos: t.pos, Msg: "unexpected " + t.describe()}
+	}
+	if e.kind != kindBool {
+		return nil, &CompileError{Pos: 0, Msg: fmt.Sprintf("condition must be a boolean, got a %s", e.kind)}
+	}
+	return &Condition{source: source, eval: e.truth, usesPath: p.usesPath}, nil
+}
+
+// Match évalue la condition sur une visite.
+func (c *Condition) Match(r *Request) bool {
+	return c.eval(r)
+}
+
+// UsesPath indique si la condition lit l'attribut path, le chemin qui suit le code court.
+func (c *Condition) UsesPath() bool {
+	return c.usesPath
+}
+
+// String renvoie le texte source de la condition.
+func (c *Condition) String() string {
+	return c.source
+}
+
+// valueKind est le type d'une expression.
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindNumber
+	kindBool
+	kindList
+)
+
+func (k valueKind) String() string {
+	switch k {
+	case kindString:
+		return "string"
+	case kindNumber:
+		return "number"
+	case kindBool:
+		return "boolean"
+	default:
+		return "list"
+	}
+}
+
+// expr est une expression compilée. Selon son type, une seule des fonctions d'évaluation est définie ;
+// une liste n'existe que sous forme littérale. literal est renseigné pour les chaînes littérales,
+// que certaines fonctions exigent (noms, expressions régulières, plages CIDR, fuseaux).
+type expr struct {
+	kind    valueKind
+	pos     int
+	str     func(*Request) string
+	num     func(*Request) float64
+	truth   func(*Request) bool
+	items   []*expr
+	literal *string
+}
+
+// parser construit les expressions compilées par descente récursive. Grammaire :
+//
+//	or         = and { "||" and }
+//	and        = not { "&&" not }
+//	not        = "!" not | comparison
+//	comparison = primary [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) primary | "in" list ]
+//	primary    = string | number | "true" | "false" | list | attribute | call | "(" or ")"
+type parser struct {
+	tokens   []token
+	pos      int
+	depth    int
+	usesPath bool // L'attribut path apparaît dans la condition
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	if t := p.next(); t.kind != kind {
+		return &CompileError{Pos: t.pos, Msg: fmt.Sprintf("expected %q, got %s", text, t.describe())}
+	}
+	return nil
+}
+
+// enter compte un niveau d'imbrication et refuse les expressions trop profondes.
+func (p *parser) enter(pos int) error {
+	p.depth++
+	if p.depth > maxDepth {
+		return &CompileError{Pos: pos, Msg: fmt.Sprintf("expression is nested more than %d levels deep", maxDepth)}
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (*expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOperator && p.peek().text == "||" {
+		op := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if err := requireKind(op, kindBool, left, right); err != nil {
+			return nil, err
+		}
+		l, r := left.truth, right.truth
+		left = &expr{kind: kindBool, pos: left.pos, truth: func(req *Request) bool { return l(req) || r(req) }}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (*expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOperator && p.peek().text == "&&" {
+		op := p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if err := requireKind(op, kindBool, left, right); err != nil {
+			return nil, err
+		}
+		l, r := left.truth, right.truth
+		left = &expr{kind: kindBool, pos: left.pos, truth: func(req *Request) bool { return l(req) && r(req) }}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (*expr, error) {
+	if t := p.peek(); t.kind == tokenOperator && t.text == "!" {
+		p.next()
+		if err := p.enter(t.pos); err != nil {
+			return nil, err
+		}
+		operand, err := p.parseNot()
+		p.depth--
+		if err != nil {
+			return nil, err
+		}
+		if err := requireKind(t, kindBool, operand); err != nil {
+			return nil, err
+		}
+		f := operand.truth
+		return &expr{kind: kindBool, pos: t.pos, truth: func(req *Request) bool { return !f(req) }}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (*expr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	switch {
+	case t.kind == tokenIdent && t.text == "in":
+		p.next()
+		list, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return compileIn(t, left, list)
+	case t.kind == tokenOperator && t.text != "&&" && t.text != "||" && t.text != "!":
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return compileComparison(t, left, right)
+	}
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (*expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		value := t.text
+		return &expr{kind: kindString, pos: t.pos, literal: &value, str: func(*Request) string { return value }}, nil
+	case tokenNumber:
+		value, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, &CompileError{Pos: t.pos, Msg: fmt.Sprintf("invalid number %q", t.text)}
+		}
+		return &expr{kind: kindNumber, pos: t.pos, num: func(*Request) float64 { return value }}, nil
+	case tokenLParen:
+		if err := p.enter(t.pos); err != nil {
+			return nil, err
+		}
+		e, err := p.parseOr()
+		p.depth--
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(tokenRParen, ")")
+	case tokenLBracket:
+		return p.parseList(t)
+	case tokenIdent:
+		switch t.text {
+		case "true", "false":
+			value := t.text == "true"
+			return &expr{kind: kindBool, pos: t.pos, truth: func(*Request) bool { return value }}, nil
+		}
+		if p.peek().kind == tokenLParen {
+			return p.parseCall(t)
+		}
+		if t.text == "path" {
+			p.usesPath = true
+		}
+		return compileAttribute(t)
+	}
+	return nil, &CompileError{Pos: t.pos, Msg: "unexpected " + t.describe()}
+}
+
+// parseList lit une liste littérale de chaînes ou de nombres, tous du même type.
+func (p *parser) parseList(open token) (*expr, error) {
+	list := &expr{kind: kindList, pos: open.pos}
+	for {
+		t := p.next()
+		if t.kind == tokenRBracket && len(list.items) == 0 {
+			return nil, &CompileError{Pos: t.pos, Msg: "list is empty"}
+		}
+		var item *expr
+		switch t.kind {
+		case tokenString:
+			value := t.text
+			item = &expr{kind: kindString, pos: t.pos, literal: &value, str: func(*Request) string { return value }}
+		case tokenNumber:
+			value, err := strconv.ParseFloat(t.text, 64)
+			if err != nil {
+				return nil, &CompileError{Pos: t.pos, Msg: fmt.Sprintf("invalid number %q", t.text)}
+			}
+			item = &expr{kind: kindNumber, pos: t.pos, num: func(*Request) float64 { return value }}
+		default:
+			return nil, &CompileError{Pos: t.pos, Msg: "list items must be string or number literals"}
+		}
+		if len(list.items) > 0 && item.kind != list.items[0].kind {
+			return nil, &CompileError{Pos: t.pos, Msg: "list items must all have the same type"}
+		}
+		if len(list.items) == maxListItems {
+			return nil, &CompileError{Pos: t.pos, Msg: fmt.Sprintf("list has more than %d items", maxListItems)}
+		}
+		list.items = append(list.items, item)
+
+		switch sep := p.next(); sep.kind {
+		case tokenComma:
+		case tokenRBracket:
+			return list, nil
+		default:
+			return nil, &CompileError{Pos: sep.pos, Msg: "expected \",\" or \"]\", got " + sep.describe()}
+		}
+	}
+}
+
+// parseCall lit les arguments d'un appel de fonction et le compile.
+func (p *parser) parseCall(name token) (*expr, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, &CompileError{Pos: name.pos, Msg: fmt.Sprintf("unknown function %q", name.text)}
+	}
+	if err := p.enter(name.pos); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	p.next() // "("
+	var args []*expr
+	if p.peek().kind != tokenRParen {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if err := p.expect(tokenRParen, ")"); err != nil {
+		return nil, err
+	}
+	return fn(name, args)
+}
+
+// requireKind vérifie que les opérandes d'un opérateur ont le type attendu.
+func requireKind(op token, kind valueKind, operands ...*expr) error {
+	for _, operand := range operands {
+		if operand.kind != kind {
+			return &CompileError{Pos: op.pos, Msg: fmt.Sprintf("operator %q expects %s operands, got a %s", op.text, kind, operand.kind)}
+		}
+	}
+	return nil
+}
+
+// compileComparison compile une comparaison. L'égalité s'applique à deux valeurs du même type,
+// les comparaisons d'ordre aux nombres uniquement.
+func compileComparison(op token, left, right *expr) (*expr, error) {
+	if left.kind == kindList || right.kind == kindList {
+		return nil, &CompileError{Pos: op.pos, Msg: fmt.Sprintf("operator %q cannot compare lists, use \"in\"", op.text)}
+	}
+	if left.kind != right.kind {
+		return nil, &CompileError{Pos: op.pos, Msg: fmt.Sprintf("operator %q cannot compare a %s with a %s", op.text, left.kind, right.kind)}
+	}
+
+	result := &expr{kind: kindBool, pos: left.pos}
+	switch op.text {
+	case "==", "!=":
+		negate := op.text == "!="
+		switch left.kind {
+		case kindString:
+			l, r := left.str, right.str
+			result.truth = func(req *Request) bool { return (l(req) == r(req)) != negate }
+		case kindNumber:
+			l, r := left.num, right.num
+			result.truth = func(req *Request) bool { return (l(req) == r(req)) != negate }
+		default:
+			l, r := left.truth, right.truth
+			result.truth = func(req *Request) bool { return (l(req) == r(req)) != negate }
+		}
+		return result, nil
+	}
+
+	if err := requireKind(op, kindNumber, left, right); err != nil {
+		return nil, err
+	}
+	l, r := left.num, right.num
+	switch op.text {
+	case "<":
+		result.truth = func(req *Request) bool { return l(req) < r(req) }
+	case "<=":
+		result.truth = func(req *Request) bool { return l(req) <= r(req) }
+	case ">":
+		result.truth = func(req *Request) bool { return l(req) > r(req) }
+	default:
+		result.truth = func(req *Request) bool { return l(req) >= r(req) }
+	}
+	return result, nil
+}
+
+// compileIn compile un test d'appartenance à une liste littérale.
+func compileIn(op token, value, list *expr) (*expr, error) {
+	if list.kind != kindList {
+		return nil, &CompileError{Pos: op.pos, Msg: "operator \"in\" expects a list literal, e.g. [\"a\", \"b\"]"}
+	}
+	if value.kind != list.items[0].kind {
+		return nil, &CompileError{Pos: op.pos, Msg: fmt.Sprintf("operator \"in\" cannot look for a %s in a list of %s", value.kind, list.items[0].kind)}
+	}
+
+	if value.kind == kindString {
+		set := make(map[string]struct{}, len(list.items))
+		for _, item := range list.items {
+			set[*item.literal] = struct{}{}
+		}
+		f := value.str
+		return &expr{kind: kindBool, pos: value.pos, truth: func(req *Request) bool {
+			_, ok := set[f(req)]
+			return ok
+		}}, nil
+	}
+
+	numbers := make([]float64, len(list.items))
+	for i, item := range list.items {
+		numbers[i] = item.num(nil)
+	}
+	f := value.num
+	return &expr{kind: kindBool, pos: value.pos, truth: func(req *Request) bool {
+		n := f(req)
+		for _, candidate := range numbers {
+			if n == candidate {
+				return true
+			}
+		}
+		return false
+	}}, nil
+}
+
+// compileAttribute compile un attribut de la visite.
+func compileAttribute(t token) (*expr, error) {
+	e := &expr{kind: kindString, pos: t.pos}
+	switch t.text {
+	case "ip":
+		e.str = func(req *Request) string { return req.IP }
+	case "path":
+		e.str = func(req *Request) string { return req.Path }
+	case "ua.os":
+		e.str = func(req *Request) string { return req.UserAgent.OS }
+	case "ua.device":
+		e.str = func(req *Request) string { return req.UserAgent.Device }
+	case "ua.browser":
+		e.str = func(req *Request) string { return req.UserAgent.Browser }
+	default:
+		return nil, &CompileError{Pos: t.pos, Msg: fmt.Sprintf("unknown attribute %q, use ip, path, ua.os, ua.device, ua.browser or a function", t.text)}
+	}
+	return e, nil
+}
+
+// function compile l'appel d'une fonction du langage à partir de ses arguments compilés.
+type function func(name token, args []*expr) (*expr, error)
+
+// functions liste les fonctions du langage.
+var functions = map[string]function{
+	"header": lookupFunction(func(req *Request, name string) string { return req.Header.Get(name) }, http.CanonicalHeaderKey),
+	"query":  lookupFunction(func(req *Request, name string) string { return req.Query.Get(name) }, nil),
+	"cookie": lookupFunction(func(req *Request, name string) string { return req.Cookies[name] }, nil),
+
+	"lower":       compileLower,
+	"contains":    stringPredicate(strings.Contains),
+	"starts_with": stringPredicate(strings.HasPrefix),
+	"ends_with":   stringPredicate(strings.HasSuffix),
+	"matches":     compileMatches,
+	"ip_in":       compileIPIn,
+
+	"hour":    clockFunction(kindNumber, func(t time.Time) (float64, string) { return float64(t.Hour()), "" }),
+	"minute":  clockFunction(kindNumber, func(t time.Time) (float64, string) { return float64(t.Minute()), "" }),
+	"weekday": clockFunction(kindString, func(t time.Time) (float64, string) { return 0, weekdayNames[t.Weekday()] }),
+}
+
+// weekdayNames donne le nom renvoyé par weekday() pour chaque jour de la semaine.
+var weekdayNames = [7]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// checkArgs vérifie le nombre et le type des arguments d'un appel.
+func checkArgs(name token, args []*expr, kinds ...valueKind) error {
+	if len(args) != len(kinds) {
+		return &CompileError{Pos: name.pos, Msg: fmt.Sprintf("%s() expects %d argument(s), got %d", name.text, len(kinds), len(args))}
+	}
+	for i, arg := range args {
+		if arg.kind != kinds[i] {
+			return &CompileError{Pos: arg.pos, Msg: fmt.Sprintf("argument %d of %s() must be a %s, got a %s", i+1, name.text, kinds[i], arg.kind)}
+		}
+	}
+	return nil
+}
+
+// literalArg renvoie la valeur d'un argument qui doit être une chaîne littérale.
+func literalArg(name token, arg *expr, index int) (string, error) {
+	if arg.literal == nil {
+		return "", &CompileError{Pos: arg.pos, Msg: fmt.Sprintf("argument %d of %s() must be a string literal", index+1, name.text)}
+	}
+	return *arg.literal, nil
+}
+
+// lookupFunction construit une fonction qui lit une valeur de la visite par son nom littéral
+// (en-tête, paramètre, cookie). Une valeur absente donne une chaîne vide.
+func lookupFunction(lookup func(req *Request, name string) string, normalize func(string) string) function {
+	return func(name token, args []*expr) (*expr, error) {
+		if err := checkArgs(name, args, kindString); err != nil {
+			return nil, err
+		}
+		key, err := literalArg(name, args[0], 0)
+		if err != nil {
+			return nil, err
+		}
+		if normalize != nil {
+			key = normalize(key)
+		}
+		return &expr{kind: kindString, pos: name.pos, str: func(req *Request) string { return lookup(req, key) }}, nil
+	}
+}
+
+func compileLower(name token, args []*expr) (*expr, error) {
+	if err := checkArgs(name, args, kindString); err != nil {
+		return nil, err
+	}
+	f := args[0].str
+	return &expr{kind: kindString, pos: name.pos, str: func(req *Request) string { return strings.ToLower(f(req)) }}, nil
+}
+
+// stringPredicate construit une fonction booléenne à deux arguments chaînes.
+func stringPredicate(predicate func(s, sub string) bool) function {
+	return func(name token, args []*expr) (*expr, error) {
+		if err := checkArgs(name, args, kindString, kindString); err != nil {
+			return nil, err
+		}
+		s, sub := args[0].str, args[1].str
+		return &expr{kind: kindBool, pos: name.pos, truth: func(req *Request) bool { return predicate(s(req), sub(req)) }}, nil
+	}
+}
+
+// compileMatches compile matches(valeur, "regexp"). Les expressions régulières de Go s'évaluent
+// en temps linéaire : une expression fournie par un utilisateur ne peut pas bloquer les redirections.
+func compileMatches(name token, args []*expr) (*expr, error) {
+	if err := checkArgs(name, args, kindString, kindString); err != nil {
+		return nil, err
+	}
+	pattern, err := literalArg(name, args[1], 1)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, &CompileError{Pos: args[1].pos, Msg: fmt.Sprintf("invalid regular expression: %v", err)}
+	}
+	s := args[0].str
+	return &expr{kind: kindBool, pos: name.pos, truth: func(req *Request) bool { return re.MatchString(s(req)) }}, nil
+}
+
+// compileIPIn compile ip_in(adresse, "cidr", ...). Une adresse invalide n'appartient à aucune plage.
+func compileIPIn(name token, args []*expr) (*expr, error) {
+	if len(args) < 2 {
+		return nil, &CompileError{Pos: name.pos, Msg: "ip_in() expects an address and at least one CIDR range"}
+	}
+	kinds := make([]valueKind, len(args)) // Tous les arguments sont des chaînes (kindString est la valeur zéro)
+	if err := checkArgs(name, args, kinds...); err != nil {
+		return nil, err
+	}
+
+	prefixes := make([]netip.Prefix, 0, len(args)-1)
+	for i, arg := range args[1:] {
+		cidr, err := literalArg(name, arg, i+1)
+		if err != nil {
+			return nil, err
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, &CompileError{Pos: arg.pos, Msg: fmt.Sprintf("invalid CIDR range %q", cidr)}
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	address := args[0].str
+	return &expr{kind: kindBool, pos: name.pos, truth: func(req *Request) bool {
+		ip, err := netip.ParseAddr(address(req))
+		if err != nil {
+			return false
+		}
+		ip = ip.Unmap()
+		for _, prefix := range prefixes {
+			if prefix.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}}, nil
+}
+
+// clockFunction construit une fonction qui lit l'instant de la visite, dans le fuseau horaire
+// littéral donné en argument ou en UTC sans argument.
+func clockFunction(kind valueKind, read func(t time.Time) (float64, string)) function {
+	return func(name token, args []*expr) (*expr, error) {
+		loc := time.UTC
+		switch len(args) {
+		case 0:
+		case 1:
+			tz, err := literalArg(name, args[0], 0)
+			if err != nil {
+				return nil, err
+			}
+			if loc, err = time.LoadLocation(tz); err != nil {
+				return nil, &CompileError{Pos: args[0].pos, Msg: fmt.Sprintf("unknown timezone %q", tz)}
+			}
+		default:
+			return nil, &CompileError{Pos: name.pos, Msg: fmt.Sprintf("%s() expects at most 1 argument (a timezone), got %d", name.text, len(args))}
+		}
+
+		e := &expr{kind: kind, pos: name.pos}
+		if kind == kindNumber {
+			e.num = func(req *Request) float64 { n, _ := read(req.Time.In(loc)); return n }
+		} else {
+			e.str = func(req *Request) string { _, s := read(req.Time.In(loc)); return s }
+		}
+		return e, nil
+	}
+}
@@ -0,0 +1,260 @@
+package rules
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompileErrors(t *testing.T) {
+	nested := func(open, close string, depth int) string {
+		return strings.Repeat(open, depth) + "true" + strings.Repeat(close, depth)
+	}
+	items := func(n int) string {
+		return "[" + strings.TrimSuffix(strings.Repeat(`"a", `, n), ", ") + "]"
+	}
+
+	tests := []struct {
+		name    string
+		source  string
+		wantMsg string
+	}{
+		// Syntaxe et limites.
+		{"empty", "  ", "condition is empty"},
+		{"too long", `path == "` + strings.Repeat("a", MaxConditionLength) + `"`, "longer than 1000 bytes"},
+		{"unterminated string", `path == "docs`, "unterminated string"},
+		{"missing operand", `path ==`, "unexpected end of condition"},
+		{"missing parenthesis", `(path == "a"`, `expected ")"`},
+		{"trailing token", `path == "a")`, `unexpected ")"`},
+		{"two operands", `path "a"`, `unexpected "a"`},
+		{"invalid number", `hour() == 1.2.3`, `invalid number "1.2.3"`},
+		{"parentheses too deep", nested("(", ")", maxDepth+1), "nested more than 32 levels"},
+		{"negations too deep", strings.Repeat("!", maxDepth+1) + "true", "nested more than 32 levels"},
+		{"calls too deep", strings.Repeat("lower(", maxDepth+1) + "path" + strings.Repeat(")", maxDepth+1) + ` == "a"`, "nested more than 32 levels"},
+		{"empty list", `path in []`, "list is empty"},
+		{"list too long", `path in ` + items(maxListItems+1), "more than 100 items"},
+		{"list of attributes", `path in [ip]`, "list items must be string or number literals"},
+		{"mixed list", `path in ["a", 1]`, "same type"},
+		{"list separator", `path in ["a" "b"]`, `expected "," or "]"`},
+
+		// Types.
+		{"not a boolean", `path`, "condition must be a boolean, got a string"},
+		{"number condition", `hour()`, "condition must be a boolean, got a number"},
+		{"string and number", `path == 1`, "cannot compare a string with a number"},
+		{"ordering strings", `path < "b"`, `operator "<" expects number operands, got a string`},
+		{"ordering booleans", `true >= false`, `operator ">=" expects number operands, got a boolean`},
+		{"comparing lists", `["a"] == ["a"]`, "cannot compare lists"},
+		{"and with a string", `true && path`, `operator "&&" expects boolean operands, got a string`},
+		{"or with a number", `hour() || true`, `operator "||" expects boolean operands, got a number`},
+		{"not a string", `!path`, `operator "!" expects boolean operands, got a string`},
+		{"in without a list", `path in "a"`, "expects a list literal"},
+		{"in with the wrong item type", `path in [1, 2]`, "cannot look for a string in a list of number"},
+		{"list outside in", `["a"]`, "condition must be a boolean, got a list"},
+
+		// Attributs et fonctions.
+		{"unknown attribute", `host == "a"`, `unknown attribute "host"`},
+		{"unknown function", `upper(path) == "A"`, `unknown function "upper"`},
+		{"wrong argument count", `header("a", "b") == ""`, "header() expects 1 argument(s), got 2"},
+		{"wrong argument type", `contains(path, 1)`, "argument 2 of contains() must be a string, got a number"},
+		{"header name not literal", `header(path) == ""`, "argument 1 of header() must be a string literal"},
+		{"invalid regexp", `matches(path, "(")`, "invalid regular expression"},
+		{"regexp not literal", `matches(path, query("re"))`, "argument 2 of matches() must be a string literal"},
+		{"ip_in without range", `ip_in(ip)`, "at least one CIDR range"},
+		{"ip_in invalid CIDR", `ip_in(ip, "10.0.0.0/33")`, `invalid CIDR range "10.0.0.0/33"`},
+		{"ip_in address", `ip_in(ip, "10.0.0.1")`, `invalid CIDR range "10.0.0.1"`},
+		{"ip_in second CIDR invalid", `ip_in(ip, "10.0.0.0/8", "local")`, `invalid CIDR range "local"`},
+		{"ip_in range not literal", `ip_in(ip, path)`, "argument 2 of ip_in() must be a string literal"},
+		{"ip_in number", `ip_in(ip, 10)`, "argument 2 of ip_in() must be a string, got a number"},
+		{"unknown timezone", `hour("Mars/Olympus") == 9`, `unknown timezone "Mars/Olympus"`},
+		{"timezone not literal", `hour(query("tz")) == 9`, "argument 1 of hour() must be a string literal"},
+		{"too many clock arguments", `weekday("UTC", "UTC") == "mon"`, "weekday() expects at most 1 argument"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.source)
+			var compileErr *CompileError
+			if !errors.As(err, &compileErr) {
+				t.Fatalf("Compile(%q) error = %v, want a *CompileError", tt.source, err)
+			}
+			if !strings.Contains(compileErr.Msg, tt.wantMsg) {
+				t.Errorf("Compile(%q) error = %v, want %q", tt.source, err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCompileLimits(t *testing.T) {
+	tests := map[string]string{
+		"longest condition":  `path == "` + strings.Repeat("a", MaxConditionLength-10) + `"`,
+		"deepest nesting":    strings.Repeat("(", maxDepth) + "true" + strings.Repeat(")", maxDepth),
+		"deepest negation":   strings.Repeat("!", maxDepth) + "true",
+		"longest list":       "path in [" + strings.TrimSuffix(strings.Repeat(`"a",`, maxListItems), ",") + "]",
+		"sequential nesting": strings.Repeat("(true) && ", maxDepth+10) + "true",
+	}
+	for name, source := range tests {
+		if _, err := Compile(source); err != nil {
+			t.Errorf("%s: Compile error = %v, want success", name, err)
+		}
+	}
+}
+
+// visit est la visite sur laquelle les conditions de TestMatch sont évaluées : un lundi à 07:30 UTC.
+var visit = &Request{
+	Header:    http.Header{"X-Team": {"beta"}},
+	Query:     url.Values{"utm_source": {"newsletter"}},
+	Cookies:   map[string]string{"plan": "pro"},
+	IP:        "10.1.2.3",
+	Path:      "/docs/page",
+	UserAgent: UserAgent{OS: "ios", Device: "mobile", Browser: "safari"},
+	Time:      time.Date(2026, 10, 12, 7, 30, 0, 0, time.UTC),
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		// Attributs et fonctions de lecture.
+		{`header("X-Team") == "beta"`, true},
+		{`header("x-team") == "beta"`, true},
+		{`header("X-Missing") == ""`, true},
+		{`query("utm_source") == "newsletter"`, true},
+		{`query("UTM_SOURCE") == ""`, true},
+		{`cookie("plan") == "pro"`, true},
+		{`cookie("Plan") == ""`, true},
+		{`ip == "10.1.2.3"`, true},
+		{`path == "/docs/page"`, true},
+		{`ua.os == "ios" && ua.device == "mobile" && ua.browser == "safari"`, true},
+
+		// Égalité et ordre.
+		{`path != "/docs"`, true},
+		{`1 == 1.0`, true},
+		{`1 != 2`, true},
+		{`1 < 2`, true},
+		{`2 < 2`, false},
+		{`2 <= 2`, true},
+		{`3 > 2`, true},
+		{`2 > 2`, false},
+		{`2 >= 3`, false},
+		{`true == true`, true},
+		{`true != false`, true},
+		{`'a"b' == "a\"b"`, true},
+
+		// Opérateurs logiques et priorités.
+		{`!false`, true},
+		{`!!true`, true},
+		{`true && false`, false},
+		{`false || true`, true},
+		{`true || false && false`, true},
+		{`(true || false) && false`, false},
+		{`!(1 > 2) && !false`, true},
+
+		// in.
+		{`query("utm_source") in ["newsletter", "mail"]`, true},
+		{`ua.os in ["android", "windows"]`, false},
+		{`hour() in [7, 8]`, true},
+		{`minute() in [0, 15]`, false},
+
+		// Fonctions sur les chaînes.
+		{`lower("BeTa") == "beta"`, true},
+		{`lower(header("X-Team")) == "beta"`, true},
+		{`contains(path, "docs")`, true},
+		{`contains(path, "blog")`, false},
+		{`starts_with(path, "/docs/")`, true},
+		{`ends_with(path, "/page")`, true},
+		{`ends_with(path, "/docs")`, false},
+
+		// matches : recherche non ancrée, sauf ancres explicites.
+		{`matches(path, "^/docs/[a-z]+$")`, true},
+		{`matches(path, "page")`, true},
+		{`matches(path, "^page")`, false},
+		{`matches(ua.browser, "(?i)SAFARI")`, true},
+
+		// ip_in.
+		{`ip_in(ip, "10.0.0.0/8")`, true},
+		{`ip_in(ip, "192.168.0.0/16", "10.1.0.0/16")`, true},
+		{`ip_in(ip, "192.168.0.0/16")`, false},
+		{`ip_in(ip, "10.1.2.3/32")`, true},
+		{`ip_in(ip, "10.1.2.3/8")`, true}, // Plage ramenée à son préfixe
+		{`ip_in("::ffff:10.1.2.3", "10.0.0.0/8")`, true},
+		{`ip_in("2001:db8::1", "2001:db8::/32")`, true},
+		{`ip_in("2001:db8::1", "10.0.0.0/8")`, false},
+		{`ip_in(header("X-Forwarded-For"), "0.0.0.0/0")`, false}, // Adresse absente
+		{`ip_in("not an ip", "0.0.0.0/0")`, false},
+
+		// Horloge : UTC sans argument, sinon le fuseau donné.
+		{`hour() == 7`, true},
+		{`minute() == 30`, true},
+		{`weekday() == "mon"`, true},
+		{`hour("Europe/Paris") == 9`, true},
+		{`hour("America/New_York") == 3`, true},
+		{`weekday("Asia/Tokyo") == "mon"`, true},
+		{`weekday("Pacific/Honolulu") == "sun"`, true},
+		{`hour("Europe/Paris") >= 9 && hour("Europe/Paris") < 18 && weekday("Europe/Paris") in ["mon", "tue"]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			condition, err := Compile(tt.source)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			if got := condition.Match(visit); got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchEmptyRequest(t *testing.T) {
+	// En-têtes, paramètres et cookies absents (maps nil) : les lectures donnent une chaîne vide.
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{`header("X-Team") == ""`, true},
+		{`query("utm_source") == ""`, true},
+		{`cookie("plan") == ""`, true},
+		{`cookie("plan") in ["pro", "team"]`, false},
+		{`contains(header("Referer"), "example")`, false},
+		{`ip_in(ip, "0.0.0.0/0", "::/0")`, false},
+		{`matches(path, "^$")`, true},
+		{`ua.device == ""`, true},
+		{`weekday() == "mon"`, true}, // Instant zéro : lundi 1er janvier de l'an 1
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			condition, err := Compile(tt.source)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			if got := condition.Match(&Request{}); got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionUsesPath(t *testing.T) {
+	tests := map[string]bool{
+		`path == "/docs"`:                       true,
+		`ip == "1.2.3.4" || matches(path, "a")`: true,
+		`header("path") == "/docs"`:             false,
+		`query("path") == "/docs"`:              false,
+		`ua.os == "ios"`:                        false,
+	}
+	for source, want := range tests {
+		condition, err := Compile(source)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", source, err)
+		}
+		if got := condition.UsesPath(); got != want {
+			t.Errorf("UsesPath(%q) = %v, want %v", source, got, want)
+		}
+		if condition.String() != source {
+			t.Errorf("String() = %q, want %q", condition.String(), source)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenKind identifie la nature d'un lexème.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator // == != < <= > >= && || !
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenComma
+)
+
+// token est un lexème d'une condition, avec sa position (en octets) pour les messages d'erreur.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// describe décrit le lexème dans un message d'erreur.
+func (t token) describe() string {
+	if t.kind == tokenEOF {
+		return "end of condition"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// operators liste les opérateurs reconnus, les plus longs en premier.
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!"}
+
+// tokenize découpe une condition en lexèmes. Les identifiants peuvent contenir des points
+// (ex: "ua.os") ; les chaînes sont délimitées par des guillemets simples ou doubles et
+// acceptent l'échappement par barre oblique inverse.
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case c == '[':
+			tokens = append(tokens, token{tokenLBracket, "[", i})
+			i++
+		case c == ']':
+			tokens = append(tokens, token{tokenRBracket, "]", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+		case c == '"' || c == '\'':
+			text, next, err := readString(source, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokenString, text, i})
+			i = next
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(source) && (source[i] >= '0' && source[i] <= '9' || source[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokenNumber, source[start:i], start})
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(source) && (source[i] == '_' || source[i] == '.' || isLetter(source[i]) || source[i] >= '0' && source[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdent, source[start:i], start})
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(source[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, &CompileError{Pos: i, Msg: fmt.Sprintf("unexpected character %q", c)}
+			}
+			tokens = append(tokens, token{tokenOperator, op, i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(source)}), nil
+}
+
+// readString lit une chaîne littérale commençant à start et renvoie son contenu et la position qui la suit.
+func readString(source string, start int) (string, int, error) {
+	quote := source[start]
+	var b strings.Builder
+	for i := start + 1; i < len(source); i++ {
+		switch source[i] {
+		case '\\':
+			if i+1 >= len(source) {
+				return "", 0, &CompileError{Pos: i, Msg: "unterminated escape sequence"}
+			}
+			i++
+			b.WriteByte(source[i])
+		case quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(source[i])
+		}
+	}
+	return "", 0, &CompileError{Pos: start, Msg: "unterminated string"}
+}
+
+// isLetter indique si un octet est une lettre ASCII : les identifiants du langage sont en ASCII.
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
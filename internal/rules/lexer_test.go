@@ -0,0 +1,63 @@
+package rules
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tokens, err := tokenize(`header("X-Team")=="beta"&&!(ua.os in ['ios', "a\"b"]) || hour() >= 9.5`)
+	if err != nil {
+		t.Fatalf("tokenize: %v", err)
+	}
+
+	type lexeme struct {
+		kind tokenKind
+		text string
+	}
+	var got []lexeme
+	for _, tok := range tokens {
+		got = append(got, lexeme{tok.kind, tok.text})
+	}
+	want := []lexeme{
+		{tokenIdent, "header"}, {tokenLParen, "("}, {tokenString, "X-Team"}, {tokenRParen, ")"},
+		{tokenOperator, "=="}, {tokenString, "beta"}, {tokenOperator, "&&"}, {tokenOperator, "!"},
+		{tokenLParen, "("}, {tokenIdent, "ua.os"}, {tokenIdent, "in"}, {tokenLBracket, "["},
+		{tokenString, "ios"}, {tokenComma, ","}, {tokenString, `a"b`}, {tokenRBracket, "]"},
+		{tokenRParen, ")"}, {tokenOperator, "||"}, {tokenIdent, "hour"}, {tokenLParen, "("},
+		{tokenRParen, ")"}, {tokenOperator, ">="}, {tokenNumber, "9.5"}, {tokenEOF, ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenize =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	tests := []struct {
+		source  string
+		wantPos int
+		wantMsg string
+	}{
+		{`path == "docs`, 8, "unterminated string"},
+		{`path == 'docs"`, 8, "unterminated string"},
+		{`path == "docs\`, 13, "unterminated escape sequence"},
+		{`path # "docs"`, 5, "unexpected character '#'"},
+		{`path & "docs"`, 5, "unexpected character '&'"},
+		{`path = "docs"`, 5, "unexpected character '='"},
+		{`pâth == "docs"`, 1, "unexpected character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			_, err := tokenize(tt.source)
+			var compileErr *CompileError
+			if !errors.As(err, &compileErr) {
+				t.Fatalf("tokenize error = %v, want a *CompileError", err)
+			}
+			if compileErr.Pos != tt.wantPos || !strings.Contains(compileErr.Msg, tt.wantMsg) {
+				t.Errorf("tokenize error = %v, want position %d: %s", err, tt.wantPos, tt.wantMsg)
+			}
+		})
+	}
+}
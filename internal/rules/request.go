@@ -0,0 +1,36 @@
+// Package rules implémente le petit langage d'expressions des règles de redirection.
+//
+// Une condition est une expression booléenne évaluée sur les attributs d'une visite :
+//
+//	header("X-Team") == "beta" && query("utm_source") in ["newsletter", "mail"]
+//	ip_in(ip, "10.0.0.0/8", "192.168.0.0/16") || cookie("plan") == "pro"
+//	ua.device == "mobile" && hour("Europe/Paris") >= 9 && weekday("Europe/Paris") in ["mon", "fri"]
+//
+// Le langage n'a ni variables, ni boucles, ni appels extérieurs : son évaluation est bornée
+// par la taille de l'expression. Les expressions régulières, plages CIDR et fuseaux horaires
+// sont des littéraux, validés et préparés à la compilation.
+package rules
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// UserAgent décrit l'appareil du visiteur, tel qu'analysé à partir de son en-tête User-Agent.
+type UserAgent struct {
+	OS      string
+	Device  string
+	Browser string
+}
+
+// Request regroupe les attributs d'une visite sur lesquels portent les conditions.
+type Request struct {
+	Header    http.Header
+	Query     url.Values
+	Cookies   map[string]string
+	IP        string    // Adresse IP du visiteur
+	Path      string    // Chemin qui suit le code court
+	UserAgent UserAgent // Appareil du visiteur
+	Time      time.Time // Instant de la visite
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Contraintes appliquées aux alias personnalisés proposés par les utilisateurs.
+const (
+	aliasMinLength = 3
+	aliasMaxLength = 32
+)
+
+// aliasPattern définit le jeu de caractères autorisé pour un alias : lettres, chiffres, '-' et '_'.
+var aliasPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Erreurs liées aux alias personnalisés
+var (
+	ErrInvalidAlias  = newDomainError(ErrInvalidInput, "invalid_alias", "alias is invalid")
+	ErrReservedAlias = newDomainError(ErrInvalidInput, "reserved_alias", "alias is reserved")
+	ErrAliasTaken    = newDomainError(ErrConflict, "alias_taken", "alias is already taken")
+)
+
+// ValidateAlias vérifie qu'un alias respecte le jeu de caractères et les longueurs minimale et maximale.
+// Les mots réservés sont vérifiés par le LinkService, selon son registre configuré.
+func ValidateAlias(alias string) error {
+	if len(alias) < aliasMinLength || len(alias) > aliasMaxLength {
+		return fmt.Errorf("%w: length must be between %d and %d characters", ErrInvalidAlias, aliasMinLength, aliasMaxLength)
+	}
+
+	if !aliasPattern.MatchString(alias) {
+		return fmt.Errorf("%w: only letters, digits, '-' and '_' are allowed", ErrInvalidAlias)
+	}
+
+	return nil
+}
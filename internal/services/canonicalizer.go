@@ -0,0 +1,145 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// DefaultTrackingParams liste les paramètres de suivi retirés par défaut des URLs longues.
+// Un motif terminé par '*' correspond à tous les paramètres qui commencent par ce préfixe.
+var DefaultTrackingParams = []string{"utm_*", "fbclid", "gclid", "dclid", "msclkid", "mc_cid", "mc_eid", "_ga", "igshid", "yclid"}
+
+// defaultPorts associe chaque schéma accepté à son port par défaut, retiré lors de la canonicalisation.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// ErrInvalidURL est renvoyée lorsqu'une URL longue ne peut pas être raccourcie.
+var ErrInvalidURL = newDomainError(ErrInvalidInput, "invalid_url", "URL is invalid")
+
+// URLCanonicalizer met les URLs longues sous une forme canonique avant leur enregistrement,
+// afin que deux écritures équivalentes d'une même destination soient stockées à l'identique.
+type URLCanonicalizer struct {
+	trackingParams []string
+}
+
+// NewURLCanonicalizer crée un canonicaliseur qui retire les paramètres de suivi donnés.
+func NewURLCanonicalizer(trackingParams []string) *URLCanonicalizer {
+	params := make([]string, 0, len(trackingParams))
+	for _, p := range trackingParams {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			params = append(params, p)
+		}
+	}
+	return &URLCanonicalizer{trackingParams: params}
+}
+
+// Canonicalize valide une URL longue et renvoie sa forme canonique :
+//   - seuls les schémas http et https sont acceptés ;
+//   - le schéma et l'hôte sont mis en minuscules, les noms de domaine internationalisés convertis en punycode ;
+//   - le port par défaut du schéma est retiré et un chemin vide devient "/" ;
+//   - les paramètres de suivi sont retirés et les paramètres restants triés par nom.
+func (c *URLCanonicalizer) Canonicalize(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	defaultPort, ok := defaultPorts[u.Scheme]
+	if !ok {
+		return "", fmt.Errorf("%w: scheme '%s' is not allowed, only http and https are accepted", ErrInvalidURL, u.Scheme)
+	}
+	if u.Opaque != "" || u.Host == "" {
+		return "", fmt.Errorf("%w: missing host", ErrInvalidURL)
+	}
+
+	host, err := canonicalHost(u.Hostname())
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid host: %v", ErrInvalidURL, err)
+	}
+	if port := u.Port(); port != "" && port != defaultPort {
+		u.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		u.Host = "[" + host + "]" // Adresse IPv6
+	} else {
+		u.Host = host
+	}
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.RawQuery = c.canonicalQuery(u.RawQuery)
+	u.ForceQuery = false
+
+	return u.String(), nil
+}
+
+// canonicalHost met un hôte en minuscules et convertit les noms internationalisés en punycode.
+func canonicalHost(host string) (string, error) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return "", fmt.Errorf("empty host")
+	}
+	if net.ParseIP(host) != nil {
+		return host, nil
+	}
+	return idna.Lookup.ToASCII(host)
+}
+
+// canonicalQuery retire les paramètres de suivi d'une query string et trie les paramètres restants
+// par nom. L'ordre relatif des valeurs d'un même paramètre et leur encodage d'origine sont conservés.
+func (c *URLCanonicalizer) canonicalQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	type param struct {
+		name string
+		raw  string
+	}
+	var params []param
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		rawName, _, _ := strings.Cut(part, "=")
+		name, err := url.QueryUnescape(rawName)
+		if err != nil {
+			name = rawName
+		}
+		if c.isTrackingParam(name) {
+			continue
+		}
+		params = append(params, param{name: name, raw: part})
+	}
+
+	sort.SliceStable(params, func(i, j int) bool { return params[i].name < params[j].name })
+
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = p.raw
+	}
+	return strings.Join(parts, "&")
+}
+
+// isTrackingParam indique si un paramètre de query correspond à l'un des motifs de suivi configurés.
+func (c *URLCanonicalizer) isTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range c.trackingParams {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,6 @@
 package services
 
 import (
-	"errors"
 	"fmt"
 	"time"
 
@@ -11,11 +10,11 @@ import (
 
 // Erreurs personnalisées pour le service
 var (
-	ErrInvalidClick     = errors.New("click data is invalid")
-	ErrInvalidLinkID    = errors.New("link ID must be greater than 0")
-	ErrInvalidTimestamp = errors.New("timestamp cannot be in the future")
-	ErrEmptyUserAgent   = errors.New("user agent cannot be empty")
-	ErrEmptyIPAddress   = errors.New("IP address cannot be empty")
+	ErrInvalidClick     = newDomainError(ErrInvalidInput, "invalid_click", "click data is invalid")
+	ErrInvalidLinkID    = newDomainError(ErrInvalidInput, "invalid_link_id", "link ID must be greater than 0")
+	ErrInvalidTimestamp = newDomainError(ErrInvalidInput, "invalid_timestamp", "timestamp cannot be in the future")
+	ErrEmptyUserAgent   = newDomainError(ErrInvalidInput, "empty_user_agent", "user agent cannot be empty")
+	ErrEmptyIPAddress   = newDomainError(ErrInvalidInput, "empty_ip_address", "IP address cannot be empty")
 )
 
 // TODO : créer la struct
@@ -83,3 +82,17 @@ func (s *ClickService) GetClicksCountByLinkID(linkID uint) (int, error) {
 
 	return count, nil
 }
+
+// GetRecentClicks récupère les derniers clics enregistrés pour un LinkID donné.
+func (s *ClickService) GetRecentClicks(linkID uint, limit int) ([]models.Click, error) {
+	if linkID == 0 {
+		return nil, fmt.Errorf("click service error: %w", ErrInvalidLinkID)
+	}
+
+	clicks, err := s.clickRepo.GetRecentClicksByLinkID(linkID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve recent clicks for LinkID %d: %w", linkID, err)
+	}
+
+	return clicks, nil
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Alphabets prédéfinis pour la génération des codes courts.
+const (
+	// Base62Alphabet est l'alphabet historique : lettres minuscules, majuscules et chiffres.
+	Base62Alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	// UnambiguousAlphabet exclut les caractères faciles à confondre (0/O/o, 1/l/I).
+	UnambiguousAlphabet = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// Stratégies de génération sélectionnables via la configuration.
+const (
+	StrategyRandom        = "random"
+	StrategySequential    = "sequential"
+	StrategyHash          = "hash"
+	StrategyPronounceable = "pronounceable"
+)
+
+// Lettres utilisées par la stratégie prononçable, qui alterne consonnes et voyelles.
+const (
+	pronounceableConsonants = "bcdfghjklmnprstvz"
+	pronounceableVowels     = "aeiou"
+)
+
+// ErrInvalidGeneratorConfig est renvoyée lorsque la configuration de génération des codes est invalide.
+var ErrInvalidGeneratorConfig = newDomainError(ErrInvalidInput, "invalid_generator_config", "short code generator configuration is invalid")
+
+// CodeRequest décrit le contexte d'une génération de code court.
+type CodeRequest struct {
+	Length   int    // Longueur souhaitée du code
+	LongURL  string // URL longue à raccourcir
+	Sequence uint64 // ID du prochain lien, renseigné pour les générateurs séquentiels
+	Attempt  int    // Numéro de tentative (0 pour la première), incrémenté après chaque collision
+}
+
+// CodeGenerator est l'interface implémentée par les différentes stratégies de génération de codes courts.
+// Capacity renvoie le nombre de codes distincts que la stratégie peut produire pour une longueur donnée.
+type CodeGenerator interface {
+	Generate(req CodeRequest) (string, error)
+	Capacity(length int) float64
+}
+
+// sequenceBased est implémentée par les générateurs qui ont besoin de l'ID du prochain lien.
+type sequenceBased interface {
+	usesSequence()
+}
+
+// NewCodeGenerator construit le générateur correspondant à la stratégie demandée.
+// L'alphabet peut être le nom d'un alphabet prédéfini ("base62", "unambiguous") ou une liste
+// de caractères personnalisée. Il est ignoré par la stratégie prononçable.
+func NewCodeGenerator(strategy, alphabet string) (CodeGenerator, error) {
+	chars, err := ResolveAlphabet(alphabet)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strategy {
+	case "", StrategyRandom:
+		return &RandomCodeGenerator{alphabet: chars}, nil
+	case StrategySequential:
+		return &SequentialCodeGenerator{alphabet: chars}, nil
+	case StrategyHash:
+		return &HashCodeGenerator{alphabet: chars}, nil
+	case StrategyPronounceable:
+		return &PronounceableCodeGenerator{}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown strategy '%s'", ErrInvalidGeneratorConfig, strategy)
+	}
+}
+
+// ResolveAlphabet renvoie les caractères d'un alphabet prédéfini ou valide un alphabet personnalisé.
+// Un alphabet personnalisé doit contenir au moins deux caractères distincts, tous autorisés dans un alias.
+func ResolveAlphabet(alphabet string) (string, error) {
+	switch alphabet {
+	case "", "base62":
+		return Base62Alphabet, nil
+	case "unambiguous":
+		return UnambiguousAlphabet, nil
+	}
+
+	if !aliasPattern.MatchString(alphabet) {
+		return "", fmt.Errorf("%w: alphabet may only contain letters, digits, '-' and '_'", ErrInvalidGeneratorConfig)
+	}
+	seen := make(map[rune]bool, len(alphabet))
+	for _, r := range alphabet {
+		if seen[r] {
+			return "", fmt.Errorf("%w: alphabet contains duplicate character '%c'", ErrInvalidGeneratorConfig, r)
+		}
+		seen[r] = true
+	}
+	if len(seen) < 2 {
+		return "", fmt.Errorf("%w: alphabet must contain at least 2 characters", ErrInvalidGeneratorConfig)
+	}
+	return alphabet, nil
+}
+
+// RandomCodeGenerator tire chaque caractère uniformément dans l'alphabet avec crypto/rand.
+type RandomCodeGenerator struct {
+	alphabet string
+}
+
+// Generate génère un code aléatoire de la longueur demandée.
+func (g *RandomCodeGenerator) Generate(req CodeRequest) (string, error) {
+	if req.Length <= 0 {
+		return "", errors.New("length must be greater than 0")
+	}
+	return randomString(g.alphabet, req.Length)
+}
+
+func (g *RandomCodeGenerator) randomized() {}
+
+// Capacity renvoie la taille de l'espace des codes aléatoires de la longueur donnée.
+func (g *RandomCodeGenerator) Capacity(length int) float64 {
+	return math.Pow(float64(len(g.alphabet)), float64(length))
+}
+
+// SequentialCodeGenerator encode l'ID du prochain lien en base N (N étant la taille de l'alphabet).
+// Le code est complété à gauche pour atteindre la longueur demandée. Après une collision,
+// la séquence est décalée du numéro de tentative.
+type SequentialCodeGenerator struct {
+	alphabet string
+}
+
+func (g *SequentialCodeGenerator) usesSequence() {}
+
+// Generate encode la séquence fournie dans la requête.
+func (g *SequentialCodeGenerator) Generate(req CodeRequest) (string, error) {
+	if req.Length <= 0 {
+		return "", errors.New("length must be greater than 0")
+	}
+
+	value := new(big.Int).SetUint64(req.Sequence)
+	value.Add(value, big.NewInt(int64(req.Attempt)))
+
+	code := encodeBase(value, g.alphabet)
+	for len(code) < req.Length {
+		code = g.alphabet[:1] + code
+	}
+	return code, nil
+}
+
+// Capacity renvoie le nombre de valeurs de séquence encodables sur la longueur donnée.
+func (g *SequentialCodeGenerator) Capacity(length int) float64 {
+	return math.Pow(float64(len(g.alphabet)), float64(length))
+}
+
+// HashCodeGenerator dérive le code d'une empreinte SHA-256 de l'URL longue :
+// la même URL produit toujours le même code à la première tentative.
+type HashCodeGenerator struct {
+	alphabet string
+}
+
+// Generate calcule le code déterministe associé à l'URL longue et au numéro de tentative.
+func (g *HashCodeGenerator) Generate(req CodeRequest) (string, error) {
+	if req.Length <= 0 {
+		return "", errors.New("length must be greater than 0")
+	}
+
+	input := req.LongURL
+	if req.Attempt > 0 {
+		input += "#" + strconv.Itoa(req.Attempt)
+	}
+	sum := sha256.Sum256([]byte(input))
+
+	// Les chiffres de poids faible sont conservés : ils sont uniformément répartis.
+	code := encodeBase(new(big.Int).SetBytes(sum[:]), g.alphabet)
+	if len(code) > req.Length {
+		code = code[len(code)-req.Length:]
+	}
+	for len(code) < req.Length {
+		code = g.alphabet[:1] + code
+	}
+	return code, nil
+}
+
+// Capacity renvoie la taille de l'espace des empreintes tronquées à la longueur donnée.
+func (g *HashCodeGenerator) Capacity(length int) float64 {
+	return math.Pow(float64(len(g.alphabet)), float64(length))
+}
+
+// PronounceableCodeGenerator produit des codes faciles à lire et à dicter, en alternant
+// aléatoirement consonnes et voyelles (ex: "bakodi").
+type PronounceableCodeGenerator struct{}
+
+func (g *PronounceableCodeGenerator) randomized() {}
+
+// Generate génère un code prononçable de la longueur demandée.
+func (g *PronounceableCodeGenerator) Generate(req CodeRequest) (string, error) {
+	if req.Length <= 0 {
+		return "", errors.New("length must be greater than 0")
+	}
+
+	code := make([]byte, 0, req.Length)
+	for i := 0; i < req.Length; i++ {
+		letters := pronounceableConsonants
+		if i%2 == 1 {
+			letters = pronounceableVowels
+		}
+		c, err := randomString(letters, 1)
+		if err != nil {
+			return "", err
+		}
+		code = append(code, c[0])
+	}
+	return string(code), nil
+}
+
+// Capacity renvoie le nombre de codes prononçables distincts de la longueur donnée.
+func (g *PronounceableCodeGenerator) Capacity(length int) float64 {
+	consonants := (length + 1) / 2
+	vowels := length / 2
+	return math.Pow(float64(len(pronounceableConsonants)), float64(consonants)) *
+		math.Pow(float64(len(pronounceableVowels)), float64(vowels))
+}
+
+// randomString tire length caractères uniformément dans l'alphabet fourni.
+func randomString(alphabet string, length int) (string, error) {
+	code := make([]byte, length)
+	for i := range code {
+		index, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", fmt.Errorf("error generating random index: %w", err)
+		}
+		code[i] = alphabet[index.Int64()]
+	}
+	return string(code), nil
+}
+
+// encodeBase convertit un entier positif dans la base définie par l'alphabet.
+func encodeBase(value *big.Int, alphabet string) string {
+	base := big.NewInt(int64(len(alphabet)))
+	if value.Sign() == 0 {
+		return alphabet[:1]
+	}
+
+	n := new(big.Int).Set(value)
+	mod := new(big.Int)
+	var digits []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, base, mod)
+		digits = append(digits, alphabet[mod.Int64()])
+	}
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+	return string(digits)
+}
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrDestinationForbidden est renvoyée lorsque la destination d'un lien est refusée par la politique de domaines.
+var ErrDestinationForbidden = newDomainError(ErrForbidden, "destination_forbidden", "destination is not allowed")
+
+// policyReloadDelay regroupe les événements d'écriture successifs d'un éditeur en un seul rechargement.
+const policyReloadDelay = 200 * time.Millisecond
+
+// DomainRule est une règle de la politique de domaines. Exactement un des champs doit être renseigné :
+//   - Exact : l'hôte doit être identique ("example.com") ;
+//   - Suffix : l'hôte est le domaine ou l'un de ses sous-domaines ("example.com" couvre "www.example.com") ;
+//   - Regex : l'hôte entier doit correspondre à l'expression régulière, ancrée implicitement
+//     ("example\.com" n'accepte ni "example.com.attacker.net" ni "notexample.com").
+type DomainRule struct {
+	Exact  string `yaml:"exact"`
+	Suffix string `yaml:"suffix"`
+	Regex  string `yaml:"regex"`
+}
+
+// domainPolicyFile est le format du fichier de politique de domaines.
+type domainPolicyFile struct {
+	Allow []DomainRule `yaml:"allow"`
+	Deny  []DomainRule `yaml:"deny"`
+}
+
+// domainMatcher est une règle compilée.
+type domainMatcher struct {
+	rule  string // Forme lisible de la règle, reprise dans les messages d'erreur
+	match func(host string) bool
+}
+
+// domainRuleSet est l'ensemble des règles actives, remplacé d'un bloc à chaque rechargement.
+type domainRuleSet struct {
+	allow []domainMatcher
+	deny  []domainMatcher
+}
+
+// DestinationPolicy décide si une URL longue peut être raccourcie selon son hôte.
+// Les règles de refus sont prioritaires ; si au moins une règle d'autorisation existe,
+// seuls les hôtes qui y correspondent sont acceptés. Les liens pointant vers le service
+// lui-même sont toujours refusés, afin d'éviter les boucles de redirection : son URL publique,
+// mais aussi les adresses locales (localhost, 127.0.0.1, [::1], adresses des interfaces de la
+// machine) sur son port d'écoute.
+type DestinationPolicy struct {
+	path       string
+	selfHosts  map[string]struct{} // Hôtes (avec port) sous lesquels le service est exposé
+	selfPorts  map[string]struct{} // Ports sur lesquels le service écoute localement
+	localAddrs map[string]struct{} // Adresses IP des interfaces de la machine
+
+	mu    sync.RWMutex
+	rules *domainRuleSet
+}
+
+// LoadDestinationPolicy charge la politique de domaines depuis le fichier YAML donné.
+// Un chemin vide ou un fichier absent donne une politique sans règle, qui bloque seulement
+// les liens vers le service : selfURLs (typiquement server.base_url) et les adresses locales
+// sur listenPort (0 si le service n'écoute pas).
+func LoadDestinationPolicy(path string, listenPort int, selfURLs ...string) (*DestinationPolicy, error) {
+	p := &DestinationPolicy{
+		path:       path,
+		selfHosts:  make(map[string]struct{}),
+		selfPorts:  make(map[string]struct{}),
+		localAddrs: interfaceAddrs(),
+		rules:      &domainRuleSet{},
+	}
+	if listenPort > 0 {
+		p.selfPorts[strconv.Itoa(listenPort)] = struct{}{}
+	}
+
+	for _, raw := range selfURLs {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid service URL '%s'", raw)
+		}
+		host, err := canonicalHost(u.Hostname())
+		if err != nil {
+			return nil, fmt.Errorf("invalid service URL '%s': %v", raw, err)
+		}
+		scheme := strings.ToLower(u.Scheme)
+		p.selfHosts[hostWithPort(host, u.Port(), scheme)] = struct{}{}
+		// Une URL publique locale (http://localhost:8080) désigne aussi le port d'écoute.
+		if p.isLocalHost(host) {
+			p.selfPorts[effectivePort(u.Port(), scheme)] = struct{}{}
+		}
+	}
+
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Check vérifie qu'une URL longue, déjà mise sous forme canonique, respecte la politique.
+func (p *DestinationPolicy) Check(destination *url.URL) error {
+	host := strings.ToLower(destination.Hostname())
+
+	if _, self := p.selfHosts[hostWithPort(host, destination.Port(), destination.Scheme)]; self {
+		return fmt.Errorf("%w: '%s' points to this service", ErrDestinationForbidden, host)
+	}
+	if _, self := p.selfPorts[effectivePort(destination.Port(), destination.Scheme)]; self && p.isLocalHost(host) {
+		return fmt.Errorf("%w: '%s' points to this service", ErrDestinationForbidden, destination.Host)
+	}
+
+	p.mu.RLock()
+	rules := p.rules
+	p.mu.RUnlock()
+
+	for _, m := range rules.deny {
+		if m.match(host) {
+			return fmt.Errorf("%w: '%s' is denied by rule %s", ErrDestinationForbidden, host, m.rule)
+		}
+	}
+
+	if len(rules.allow) == 0 {
+		return nil
+	}
+	for _, m := range rules.allow {
+		if m.match(host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: '%s' is not in the allow list", ErrDestinationForbidden, host)
+}
+
+// Watch surveille le fichier de politique et recharge les règles à chaque modification.
+// Le dossier parent est surveillé plutôt que le fichier lui-même, ce qui couvre les éditeurs
+// qui remplacent le fichier et sa création ultérieure. Un fichier invalide est journalisé
+// et les règles précédentes restent en vigueur.
+func (p *DestinationPolicy) Watch() error {
+	if p.path == "" {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error creating policy watcher: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(p.path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("error watching policy file '%s': %w", p.path, err)
+	}
+
+	go func() {
+		defer watcher.Close()
+		target := filepath.Clean(p.path)
+		var timer *time.Timer
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != target {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(policyReloadDelay, func() {
+					if err := p.reload(); err != nil {
+						log.Printf("[POLICY] Rechargement de '%s' impossible, règles précédentes conservées : %v", p.path, err)
+						return
+					}
+					log.Printf("[POLICY] Politique de domaines rechargée depuis '%s'.", p.path)
+				})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("[POLICY] Erreur de surveillance de '%s' : %v", p.path, err)
+			}
+		}
+	}()
+	return nil
+}
+
+// reload relit le fichier de politique et remplace les règles actives.
+func (p *DestinationPolicy) reload() error {
+	rules, err := readDomainRules(p.path)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.rules = rules
+	p.mu.Unlock()
+	return nil
+}
+
+// readDomainRules lit et compile les règles du fichier. Un fichier absent ne contient aucune règle.
+func readDomainRules(path string) (*domainRuleSet, error) {
+	if path == "" {
+		return &domainRuleSet{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &domainRuleSet{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading policy file '%s': %w", path, err)
+	}
+
+	var file domainPolicyFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing policy file '%s': %w", path, err)
+	}
+
+	allow, err := compileDomainRules(file.Allow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allow rule in '%s': %w", path, err)
+	}
+	deny, err := compileDomainRules(file.Deny)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deny rule in '%s': %w", path, err)
+	}
+	return &domainRuleSet{allow: allow, deny: deny}, nil
+}
+
+// compileDomainRules transforme des règles en matchers. Les domaines sont mis sous la même
+// forme canonique que les hôtes des URLs (minuscules, punycode).
+func compileDomainRules(rules []DomainRule) ([]domainMatcher, error) {
+	matchers := make([]domainMatcher, 0, len(rules))
+	for i, rule := range rules {
+		set := 0
+		for _, v := range []string{rule.Exact, rule.Suffix, rule.Regex} {
+			if v != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("rule #%d must define exactly one of exact, suffix or regex", i+1)
+		}
+
+		switch {
+		case rule.Exact != "":
+			domain, err := canonicalHost(rule.Exact)
+			if err != nil {
+				return nil, fmt.Errorf("rule #%d: invalid domain '%s': %v", i+1, rule.Exact, err)
+			}
+			matchers = append(matchers, domainMatcher{
+				rule:  "exact:" + domain,
+				match: func(host string) bool { return host == domain },
+			})
+		case rule.Suffix != "":
+			domain, err := canonicalHost(strings.TrimPrefix(rule.Suffix, "."))
+			if err != nil {
+				return nil, fmt.Errorf("rule #%d: invalid domain '%s': %v", i+1, rule.Suffix, err)
+			}
+			matchers = append(matchers, domainMatcher{
+				rule: "suffix:" + domain,
+				match: func(host string) bool {
+					return host == domain || strings.HasSuffix(host, "."+domain)
+				},
+			})
+		default:
+			// L'expression est ancrée : une règle écrite sans ^ ni $ ne doit pas accepter un hôte
+			// qui la contient seulement (ex: "example.com.attacker.net" pour "example\.com").
+			re, err := regexp.Compile(`^(?:` + rule.Regex + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("rule #%d: invalid regex: %v", i+1, err)
+			}
+			matchers = append(matchers, domainMatcher{
+				rule:  "regex:" + rule.Regex,
+				match: re.MatchString,
+			})
+		}
+	}
+	return matchers, nil
+}
+
+// hostWithPort renvoie l'hôte suivi de son port effectif (le port par défaut du schéma s'il est absent).
+func hostWithPort(host, port, scheme string) string {
+	return net.JoinHostPort(host, effectivePort(port, scheme))
+}
+
+// effectivePort renvoie le port d'une URL, ou le port par défaut de son schéma s'il est absent.
+func effectivePort(port, scheme string) string {
+	if port == "" {
+		return defaultPorts[scheme]
+	}
+	return port
+}
+
+// isLocalHost indique si un hôte désigne la machine du service : nom localhost, adresse de boucle
+// locale ou non spécifiée (0.0.0.0, ::), ou adresse de l'une de ses interfaces.
+func (p *DestinationPolicy) isLocalHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() || ip.IsUnspecified() {
+		return true
+	}
+	_, local := p.localAddrs[ip.String()]
+	return local
+}
+
+// interfaceAddrs renvoie les adresses IP des interfaces réseau de la machine, sous forme canonique.
+func interfaceAddrs() map[string]struct{} {
+	addrs := make(map[string]struct{})
+	ifaceAddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		log.Printf("[POLICY] Adresses des interfaces indisponibles : %v", err)
+		return addrs
+	}
+	for _, addr := range ifaceAddrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			addrs[ipNet.IP.String()] = struct{}{}
+		}
+	}
+	return addrs
+}
+
+// WithDestinationPolicy applique une politique de domaines aux destinations des liens créés ou modifiés.
+func WithDestinationPolicy(policy *DestinationPolicy) LinkServiceOption {
+	return func(s *LinkService) {
+		s.policy = policy
+	}
+}
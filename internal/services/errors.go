@@ -0,0 +1,53 @@
+package services
+
+import (
+	"errors"
+)
+
+// Catégories d'erreurs du domaine. Toutes les erreurs métier renvoyées par les services
+// appartiennent à l'une de ces catégories, testable avec errors.Is, ce qui permet aux
+// couches supérieures (API, CLI) de les traiter sans connaître la couche de persistance.
+var (
+	ErrNotFound     = errors.New("resource not found")
+	ErrConflict     = errors.New("resource conflict")
+	ErrExpired      = errors.New("resource has expired")
+	ErrInvalidInput = errors.New("invalid input")
+	ErrForbidden    = errors.New("operation not allowed")
+	ErrUnauthorized = errors.New("authentication required")
+	ErrRateLimited  = errors.New("too many requests")
+	ErrUnavailable  = errors.New("resource is not available")
+)
+
+// ErrLinkNotFound est renvoyée lorsqu'aucun lien ne correspond au code court demandé.
+var ErrLinkNotFound = newDomainError(ErrNotFound, "link_not_found", "link not found")
+
+// DomainError est une erreur métier identifiée par un code stable, destiné aux clients de l'API.
+// Elle se rattache à une catégorie (ErrNotFound, ErrConflict...) via Unwrap.
+type DomainError struct {
+	Kind    error  // Catégorie de l'erreur
+	Code    string // Code stable, ex: "alias_taken"
+	Message string
+}
+
+// newDomainError crée une erreur métier d'une catégorie donnée.
+func newDomainError(kind error, code, message string) *DomainError {
+	return &DomainError{Kind: kind, Code: code, Message: message}
+}
+
+func (e *DomainError) Error() string {
+	return e.Message
+}
+
+func (e *DomainError) Unwrap() error {
+	return e.Kind
+}
+
+// ErrorCode renvoie le code stable de l'erreur métier contenue dans err, ou une chaîne vide
+// si err n'est pas une erreur du domaine.
+func ErrorCode(err error) string {
+	var domainErr *DomainError
+	if errors.As(err, &domainErr) {
+		return domainErr.Code
+	}
+	return ""
+}
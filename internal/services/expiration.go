@@ -0,0 +1,83 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// Erreurs liées à l'expiration des liens
+var (
+	ErrLinkExpired       = newDomainError(ErrExpired, "link_expired", "link has expired")
+	ErrInvalidExpiration = newDomainError(ErrInvalidInput, "invalid_expiration", "expiration settings are invalid")
+)
+
+// LinkLifetime décrit la durée de vie restante d'un lien, en temps et en nombre de clics.
+// Les champs pointeurs sont nil lorsque la limite correspondante n'est pas définie.
+type LinkLifetime struct {
+	ExpiresAt       *time.Time
+	RemainingTime   *time.Duration
+	MaxClicks       *int
+	RemainingClicks *int
+	SingleUse       bool
+	Expired         bool
+}
+
+// validateExpiration vérifie les paramètres d'expiration fournis à la création d'un lien.
+func validateExpiration(expiresAt *time.Time, maxClicks *int, singleUse bool, now time.Time) error {
+	if expiresAt != nil && !expiresAt.After(now) {
+		return fmt.Errorf("%w: expires_at must be in the future", ErrInvalidExpiration)
+	}
+	if maxClicks != nil && *maxClicks <= 0 {
+		return fmt.Errorf("%w: max_clicks must be greater than 0", ErrInvalidExpiration)
+	}
+	if singleUse && maxClicks != nil {
+		return fmt.Errorf("%w: a single-use link cannot have max_clicks", ErrInvalidExpiration)
+	}
+	return nil
+}
+
+// IsLinkExpired indique si un lien a expiré à l'instant donné, que ce soit par date,
+// par épuisement de son budget de clics, par consommation d'un lien à usage unique
+// ou parce que le balayeur l'a déjà marqué.
+func IsLinkExpired(link *models.Link, now time.Time) bool {
+	if link.ExpiredAt != nil {
+		return true
+	}
+	if link.ExpiresAt != nil && !now.Before(*link.ExpiresAt) {
+		return true
+	}
+	if link.SingleUse && link.UsedClicks > 0 {
+		return true
+	}
+	return link.MaxClicks != nil && link.UsedClicks >= *link.MaxClicks
+}
+
+// ComputeLifetime calcule la durée de vie restante d'un lien à l'instant donné.
+func ComputeLifetime(link *models.Link, now time.Time) LinkLifetime {
+	lifetime := LinkLifetime{
+		ExpiresAt: link.ExpiresAt,
+		MaxClicks: link.MaxClicks,
+		SingleUse: link.SingleUse,
+		Expired:   IsLinkExpired(link, now),
+	}
+
+	if link.ExpiresAt != nil {
+		remaining := link.ExpiresAt.Sub(now)
+		if remaining < 0 {
+			remaining = 0
+		}
+		lifetime.RemainingTime = &remaining
+	}
+
+	if link.MaxClicks != nil {
+		remaining := *link.MaxClicks - link.UsedClicks
+		if remaining < 0 {
+			remaining = 0
+		}
+		lifetime.RemainingClicks = &remaining
+	}
+
+	return lifetime
+}
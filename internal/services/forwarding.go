@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// Politiques de résolution des conflits lorsqu'un paramètre de la requête existe déjà dans la destination.
+const (
+	QueryConflictDestination = "destination" // La valeur de la destination est conservée (par défaut)
+	QueryConflictRequest     = "request"     // La valeur de la requête remplace celle de la destination
+	QueryConflictAppend      = "append"      // Les deux valeurs sont conservées, celle de la destination en premier
+)
+
+// Erreurs liées au transfert de la query string et du chemin
+var (
+	ErrInvalidForwarding = newDomainError(ErrInvalidInput, "invalid_forwarding", "forwarding settings are invalid")
+	ErrPathNotForwarded  = newDomainError(ErrNotFound, "path_not_forwarded", "link does not forward extra path segments")
+)
+
+// validateQueryConflict vérifie une politique de conflit. Une valeur vide désigne la politique par défaut.
+func validateQueryConflict(policy string) error {
+	switch policy {
+	case "", QueryConflictDestination, QueryConflictRequest, QueryConflictAppend:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown query conflict policy '%s', use %s, %s or %s",
+			ErrInvalidForwarding, policy, QueryConflictDestination, QueryConflictRequest, QueryConflictAppend)
+	}
+}
+
+// forwardTo construit l'URL vers laquelle rediriger une visite du lien, à partir d'une URL de base
+// (l'URL longue, celle d'une variante ou celle choisie par une règle). rest est le chemin qui suit
+// le code court dans l'URL visitée (ex: "/docs/page") et query ses paramètres. Ils ne sont repris
+// que si le lien l'autorise ; un chemin supplémentaire sur un lien qui ne le transfère pas donne
+// ErrPathNotForwarded.
+func forwardTo(link *models.Link, base, rest string, query url.Values) (string, error) {
+	rest = strings.TrimPrefix(rest, "/")
+	if rest != "" && !link.ForwardPath {
+		return "", fmt.Errorf("%w: '%s'", ErrPathNotForwarded, link.Shortcode)
+	}
+	if rest == "" && (!link.ForwardQuery || len(query) == 0) {
+		return base, nil
+	}
+
+	destination, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("error parsing destination of link '%s': %w", link.Shortcode, err)
+	}
+
+	if rest != "" {
+		// Le chemin est nettoyé pour qu'un "../" ne puisse pas sortir du chemin de la destination.
+		suffix := path.Clean("/" + rest)
+		destination.Path = strings.TrimSuffix(destination.Path, "/") + suffix
+		destination.RawPath = ""
+	}
+
+	if link.ForwardQuery && len(query) > 0 {
+		destination.RawQuery = mergeQuery(destination.Query(), query, link.QueryConflict).Encode()
+	}
+
+	return destination.String(), nil
+}
+
+// mergeQuery ajoute les paramètres de la requête à ceux de la destination selon la politique de conflit.
+func mergeQuery(destination, incoming url.Values, policy string) url.Values {
+	for name, values := range incoming {
+		if _, exists := destination[name]; !exists {
+			destination[name] = values
+			continue
+		}
+		switch policy {
+		case QueryConflictRequest:
+			destination[name] = values
+		case QueryConflictAppend:
+			destination[name] = append(destination[name], values...)
+		default:
+			// QueryConflictDestination : la valeur de la destination est conservée.
+		}
+	}
+	return destination
+}
@@ -0,0 +1,42 @@
+package services
+
+import "sync"
+
+// keyedMutex sérialise les opérations portant sur une même clé, sans bloquer les autres clés.
+// Une entrée n'existe que tant qu'un appelant la détient ou l'attend.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	mu      sync.Mutex
+	holders int // Appelants qui détiennent ou attendent le verrou
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*keyedLock)}
+}
+
+// Lock prend le verrou de la clé et renvoie la fonction qui le libère.
+func (k *keyedMutex) Lock(key string) (unlock func()) {
+	k.mu.Lock()
+	lock, ok := k.locks[key]
+	if !ok {
+		lock = &keyedLock{}
+		k.locks[key] = lock
+	}
+	lock.holders++
+	k.mu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+		k.mu.Lock()
+		lock.holders--
+		if lock.holders == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
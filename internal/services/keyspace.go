@@ -0,0 +1,239 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+)
+
+// Valeurs par défaut de l'ajustement automatique de la longueur des codes.
+const (
+	defaultCollisionThreshold = 0.2
+	defaultCollisionWindow    = 100
+	defaultMaxCodeLength      = 12
+)
+
+// randomized est implémentée par les générateurs qui tirent leurs codes au hasard : leurs collisions
+// mesurent le remplissage de l'espace des codes. Les codes séquentiels ou dérivés de l'URL entrent en
+// collision pour d'autres raisons (alias déjà pris, même URL raccourcie deux fois), qui ne justifient
+// pas d'allonger les codes.
+type randomized interface {
+	randomized()
+}
+
+// lengthTracker suit le taux de collisions des codes générés sur une fenêtre glissante de tentatives
+// et allonge les codes d'un caractère lorsque ce taux dépasse le seuil configuré.
+// La longueur atteinte n'est pas stockée : elle est déduite au premier usage de l'occupation de
+// l'espace des codes en base (voir restore), et survit ainsi aux redémarrages.
+type lengthTracker struct {
+	mu        sync.Mutex
+	restored  sync.Once
+	length    int     // Longueur actuellement utilisée pour générer les codes
+	adaptive  bool    // Active l'allongement automatique
+	threshold float64 // Taux de collisions (entre 0 et 1) déclenchant l'allongement
+	window    int     // Nombre de tentatives de la fenêtre d'observation
+	maxLength int     // Longueur maximale atteignable
+
+	attempts        int // Tentatives de la fenêtre courante
+	collisions      int // Collisions de la fenêtre courante
+	totalAttempts   uint64
+	totalCollisions uint64
+}
+
+// newLengthTracker crée un suivi à longueur fixe. L'ajustement automatique s'active via enableAdaptive.
+func newLengthTracker(length int) *lengthTracker {
+	return &lengthTracker{
+		length:    length,
+		threshold: defaultCollisionThreshold,
+		window:    defaultCollisionWindow,
+		maxLength: defaultMaxCodeLength,
+	}
+}
+
+// current renvoie la longueur de code à utiliser pour la prochaine génération.
+func (t *lengthTracker) current() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.length
+}
+
+// record enregistre le résultat d'une tentative d'insertion d'un code généré.
+// Il renvoie true si la longueur des codes vient d'être augmentée.
+func (t *lengthTracker) record(collision bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.attempts++
+	t.totalAttempts++
+	if collision {
+		t.collisions++
+		t.totalCollisions++
+	}
+
+	// Le seuil peut être franchi avant la fin de la fenêtre : inutile d'attendre pour réagir.
+	exceeded := float64(t.collisions) > t.threshold*float64(t.window)
+	if !exceeded && t.attempts < t.window {
+		return false
+	}
+
+	rate := float64(t.collisions) / float64(t.attempts)
+	t.attempts, t.collisions = 0, 0
+
+	if !t.adaptive || rate <= t.threshold {
+		return false
+	}
+	if t.length >= t.maxLength {
+		log.Printf("[KEYSPACE] WARNING: collision rate %.0f%% above threshold but code length already at maximum (%d)", rate*100, t.maxLength)
+		return false
+	}
+
+	t.length++
+	log.Printf("[KEYSPACE] WARNING: collision rate %.0f%% above threshold %.0f%%, short code length increased to %d",
+		rate*100, t.threshold*100, t.length)
+	return true
+}
+
+// grow allonge les codes d'un caractère lorsqu'une création a épuisé ses tentatives à la longueur
+// used, sans attendre la fin de la fenêtre d'observation. Il renvoie false si la longueur ne peut
+// plus augmenter. Si une autre création l'a déjà allongée, la nouvelle longueur est simplement reprise.
+func (t *lengthTracker) grow(used int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.adaptive {
+		return false
+	}
+	if t.length > used {
+		return true
+	}
+	if t.length >= t.maxLength {
+		return false
+	}
+	t.length++
+	t.attempts, t.collisions = 0, 0
+	log.Printf("[KEYSPACE] WARNING: no free short code found at length %d, short code length increased to %d", used, t.length)
+	return true
+}
+
+// restore déduit la longueur des codes de l'occupation de l'espace des codes : c'est la plus petite
+// longueur, à partir de la longueur configurée, dont l'occupation (et donc la probabilité qu'un code
+// tiré au hasard soit déjà pris) ne dépasse pas le seuil de collisions.
+func (t *lengthTracker) restore(counts map[int]int64, capacity func(length int) float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	configured := t.length
+	for t.length < t.maxLength {
+		c := capacity(t.length)
+		if c <= 0 || float64(counts[t.length])/c <= t.threshold {
+			break
+		}
+		t.length++
+	}
+	if t.length > configured {
+		log.Printf("[KEYSPACE] Short code length restored to %d from the occupancy of the keyspace (configured: %d)", t.length, configured)
+	}
+}
+
+// codeLength renvoie la longueur des codes à générer. Au premier appel, la longueur atteinte par
+// l'allongement automatique est déduite de la base pour les générateurs aléatoires.
+func (s *LinkService) codeLength() int {
+	s.lengths.restored.Do(func() {
+		if _, ok := s.generator.(randomized); !ok || !s.lengths.adaptive {
+			return
+		}
+		counts, err := s.linkRepo.CountLinksByCodeLength()
+		if err != nil {
+			log.Printf("[KEYSPACE] WARNING: cannot read keyspace occupancy, keeping configured short code length: %v", err)
+			return
+		}
+		s.lengths.restore(counts, s.generator.Capacity)
+	})
+	return s.lengths.current()
+}
+
+// WithAdaptiveLength active l'allongement automatique des codes générés lorsque le taux de collisions,
+// mesuré sur une fenêtre de window tentatives, dépasse threshold. La longueur ne dépasse jamais maxLength.
+// Les valeurs nulles ou négatives conservent les valeurs par défaut.
+func WithAdaptiveLength(threshold float64, window, maxLength int) LinkServiceOption {
+	return func(s *LinkService) {
+		s.lengths.adaptive = true
+		if threshold > 0 {
+			s.lengths.threshold = threshold
+		}
+		if window > 0 {
+			s.lengths.window = window
+		}
+		if maxLength > 0 {
+			s.lengths.maxLength = maxLength
+		}
+	}
+}
+
+// LengthOccupancy décrit l'occupation de l'espace des codes pour une longueur donnée.
+type LengthOccupancy struct {
+	Length    int     `json:"length"`
+	Links     int64   `json:"links"`
+	Capacity  float64 `json:"capacity"`
+	Occupancy float64 `json:"occupancy"` // Links / Capacity, entre 0 et 1
+}
+
+// KeyspaceReport est l'état de l'espace des codes courts exposé aux opérateurs.
+type KeyspaceReport struct {
+	CurrentLength      int               `json:"current_length"`
+	MaxLength          int               `json:"max_length"`
+	Adaptive           bool              `json:"adaptive"`
+	CollisionThreshold float64           `json:"collision_threshold"`
+	WindowSize         int               `json:"window_size"`
+	WindowAttempts     int               `json:"window_attempts"`
+	WindowCollisions   int               `json:"window_collisions"`
+	TotalAttempts      uint64            `json:"total_attempts"`
+	TotalCollisions    uint64            `json:"total_collisions"`
+	Lengths            []LengthOccupancy `json:"lengths"`
+}
+
+// GetKeyspaceReport calcule l'occupation de l'espace des codes pour chaque longueur présente en base
+// (ainsi que pour la longueur courante) et renvoie les statistiques de collisions.
+func (s *LinkService) GetKeyspaceReport() (*KeyspaceReport, error) {
+	counts, err := s.linkRepo.CountLinksByCodeLength()
+	if err != nil {
+		return nil, fmt.Errorf("error counting links by code length: %w", err)
+	}
+
+	s.codeLength() // Déduit la longueur atteinte si aucun code n'a encore été généré
+	s.lengths.mu.Lock()
+	report := &KeyspaceReport{
+		CurrentLength:      s.lengths.length,
+		MaxLength:          s.lengths.maxLength,
+		Adaptive:           s.lengths.adaptive,
+		CollisionThreshold: s.lengths.threshold,
+		WindowSize:         s.lengths.window,
+		WindowAttempts:     s.lengths.attempts,
+		WindowCollisions:   s.lengths.collisions,
+		TotalAttempts:      s.lengths.totalAttempts,
+		TotalCollisions:    s.lengths.totalCollisions,
+	}
+	s.lengths.mu.Unlock()
+
+	if _, ok := counts[report.CurrentLength]; !ok {
+		counts[report.CurrentLength] = 0
+	}
+
+	for length, links := range counts {
+		capacity := s.generator.Capacity(length)
+		occupancy := 0.0
+		if capacity > 0 {
+			occupancy = float64(links) / capacity
+		}
+		report.Lengths = append(report.Lengths, LengthOccupancy{
+			Length:    length,
+			Links:     links,
+			Capacity:  capacity,
+			Occupancy: occupancy,
+		})
+	}
+	sort.Slice(report.Lengths, func(i, j int) bool { return report.Lengths[i].Length < report.Lengths[j].Length })
+
+	return report, nil
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// maxLocalizedDestinations limite le nombre de langues d'un lien.
+const maxLocalizedDestinations = 50
+
+// languageTagPattern reconnaît une étiquette de langue BCP 47 simple : langue, puis sous-étiquettes
+// (script, région, variante) séparées par des tirets.
+var languageTagPattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{1,8})*$`)
+
+// ErrInvalidLocalization est renvoyée lorsque les destinations par langue d'un lien sont invalides.
+var ErrInvalidLocalization = newDomainError(ErrInvalidInput, "invalid_localization", "localized destinations are invalid")
+
+// canonicalLanguageTag met une étiquette de langue sous sa forme usuelle : langue en minuscules,
+// script avec une majuscule initiale et région en majuscules (ex: "zh-Hant-TW", "fr-CA").
+func canonicalLanguageTag(tag string) string {
+	parts := strings.Split(strings.ToLower(tag), "-")
+	for i := 1; i < len(parts); i++ {
+		switch {
+		case len(parts[i]) == 4 && i == 1:
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		case len(parts[i]) == 2, len(parts[i]) == 3 && parts[i][0] >= '0' && parts[i][0] <= '9':
+			parts[i] = strings.ToUpper(parts[i])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// prepareLocalized valide les destinations par langue d'un lien : étiquettes mises sous forme
+// canonique et URLs sous forme canonique. Une map vide donne nil.
+func (s *LinkService) prepareLocalized(localized map[string]string) (map[string]string, error) {
+	if len(localized) == 0 {
+		return nil, nil
+	}
+	if len(localized) > maxLocalizedDestinations {
+		return nil, fmt.Errorf("%w: a link can have at most %d languages", ErrInvalidLocalization, maxLocalizedDestinations)
+	}
+
+	prepared := make(map[string]string, len(localized))
+	for tag, longURL := range localized {
+		if !languageTagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("%w: '%s' is not a language tag (e.g. fr, en-US)", ErrInvalidLocalization, tag)
+		}
+		canonical := canonicalLanguageTag(tag)
+		if _, duplicate := prepared[canonical]; duplicate {
+			return nil, fmt.Errorf("%w: language '%s' is given twice", ErrInvalidLocalization, canonical)
+		}
+		destination, err := s.prepareDestination(longURL)
+		if err != nil {
+			return nil, fmt.Errorf("language '%s': %w", canonical, err)
+		}
+		prepared[canonical] = destination
+	}
+	return prepared, nil
+}
+
+// languagePreference est une langue demandée dans un en-tête Accept-Language, avec son poids.
+type languagePreference struct {
+	tag string
+	q   float64
+}
+
+// parseAcceptLanguage analyse un en-tête Accept-Language et renvoie les langues acceptées par
+// poids décroissant, en conservant l'ordre de l'en-tête à poids égal. Les langues de poids 0,
+// explicitement refusées, sont renvoyées à part. Les entrées mal formées sont ignorées.
+func parseAcceptLanguage(header string) (accepted []languagePreference, refused map[string]bool) {
+	refused = make(map[string]bool)
+	for _, entry := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(entry), ";")
+		tag = strings.TrimSpace(tag)
+		if tag != "*" && !languageTagPattern.MatchString(tag) {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				continue
+			}
+			q = parsed
+		}
+
+		if tag != "*" {
+			tag = canonicalLanguageTag(tag)
+		}
+		if q == 0 {
+			refused[tag] = true
+			continue
+		}
+		accepted = append(accepted, languagePreference{tag: tag, q: q})
+	}
+
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].q > accepted[j].q })
+	return accepted, refused
+}
+
+// negotiateLanguage choisit la destination d'un lien localisé pour un en-tête Accept-Language.
+// Les langues sont essayées par poids décroissant ; pour chacune, l'étiquette est raccourcie
+// sous-étiquette par sous-étiquette jusqu'à trouver une destination (fr-CA, puis fr).
+// Une langue refusée (q=0) n'est jamais choisie. Sans correspondance, la langue renvoyée est vide
+// et la destination par défaut du lien s'applique.
+func negotiateLanguage(localized map[string]string, header string) (language, destination string) {
+	if len(localized) == 0 || header == "" {
+		return "", ""
+	}
+
+	accepted, refused := parseAcceptLanguage(header)
+	for _, pref := range accepted {
+		if pref.tag == "*" {
+			// "*" accepte toutes les langues : la destination par défaut convient.
+			break
+		}
+		for candidate := pref.tag; candidate != ""; candidate = parentLanguageTag(candidate) {
+			if refused[candidate] {
+				break
+			}
+			if url, ok := localized[candidate]; ok {
+				return candidate, url
+			}
+		}
+	}
+	return "", ""
+}
+
+// parentLanguageTag retire la dernière sous-étiquette d'une étiquette de langue ("fr-CA" donne "fr").
+// Une sous-étiquette d'un seul caractère (extension) est retirée avec celle qui la suit.
+func parentLanguageTag(tag string) string {
+	i := strings.LastIndex(tag, "-")
+	if i < 0 {
+		return ""
+	}
+	tag = tag[:i]
+	if j := strings.LastIndex(tag, "-"); j >= 0 && len(tag)-j == 2 {
+		tag = tag[:j]
+	}
+	return tag
+}
+
+// LinkLanguages renvoie les langues proposées par un lien, triées, pour l'affichage.
+func LinkLanguages(link *models.Link) []string {
+	languages := make([]string, 0, len(link.Localized))
+	for tag := range link.Localized {
+		languages = append(languages, tag)
+	}
+	sort.Strings(languages)
+	return languages
+}
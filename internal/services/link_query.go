@@ -0,0 +1,114 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// Limites de pagination appliquées au listing des liens.
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// sortableLinkFields associe les champs de tri exposés par l'API aux colonnes de la table 'links'.
+var sortableLinkFields = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+	"short_code": "shortcode",
+	"long_url":   "long_url",
+}
+
+// ErrInvalidListQuery est renvoyée lorsque les paramètres de listing sont incohérents.
+var ErrInvalidListQuery = newDomainError(ErrInvalidInput, "invalid_list_query", "list query is invalid")
+
+// ListLinksQuery représente une demande de listing de liens.
+type ListLinksQuery struct {
+	Limit         int
+	Offset        int
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Domain        string
+	Search        string
+	Sort          string // Champ de tri, préfixé par '-' pour un tri décroissant (ex: "-created_at")
+}
+
+// LinkPage est une page de résultats du listing des liens.
+type LinkPage struct {
+	Links  []models.Link
+	Total  int64
+	Limit  int
+	Offset int
+}
+
+// LinkUpdate décrit une modification partielle d'un lien. Les champs nil sont laissés inchangés.
+type LinkUpdate struct {
+	LongURL        *string
+	RedirectStatus *int               // 0 rétablit le statut global
+	Headers        *map[string]string // Remplace tous les en-têtes du lien ; une map vide les retire
+	ForwardQuery   *bool
+	QueryConflict  *string
+	ForwardPath    *bool
+	Password       *string // Nouveau mot de passe ; une chaîne vide retire la protection
+	ActivateAt     *time.Time
+	Availability   *models.AvailabilityWindow // Une plage vide retire la plage de disponibilité
+	FallbackURL    *string                    // Une chaîne vide retire l'URL de repli
+	Disabled       *bool
+	Variants       *[]models.LinkVariant // Une liste vide retire les variantes ; l'URL longue reste la première
+	StickyVariants *bool
+	Targeting      *[]models.TargetingRule // Une liste vide retire les règles de ciblage
+	Localized      *map[string]string      // Une map vide retire les destinations par langue
+	TimeRules      *[]models.TimeRule      // Une liste vide retire les règles horaires
+	RedirectRules  *[]models.RedirectRule  // Une liste vide retire les règles de redirection
+}
+
+// toFilter valide la requête de listing et la traduit en filtre pour le repository.
+func (q ListLinksQuery) toFilter() (repository.LinkFilter, error) {
+	filter := repository.LinkFilter{
+		Limit:         q.Limit,
+		Offset:        q.Offset,
+		CreatedAfter:  q.CreatedAfter,
+		CreatedBefore: q.CreatedBefore,
+		Domain:        strings.ToLower(strings.TrimSpace(q.Domain)),
+		Search:        strings.TrimSpace(q.Search),
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		return filter, fmt.Errorf("%w: limit must not exceed %d", ErrInvalidListQuery, maxListLimit)
+	}
+	if filter.Offset < 0 {
+		return filter, fmt.Errorf("%w: offset must not be negative", ErrInvalidListQuery)
+	}
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		return filter, fmt.Errorf("%w: created_after must be before created_before", ErrInvalidListQuery)
+	}
+
+	if q.Sort != "" {
+		field := strings.TrimPrefix(q.Sort, "-")
+		column, ok := sortableLinkFields[field]
+		if !ok {
+			return filter, fmt.Errorf("%w: cannot sort by '%s'", ErrInvalidListQuery, field)
+		}
+		filter.SortBy = column
+		filter.SortDesc = strings.HasPrefix(q.Sort, "-")
+	}
+
+	return filter, nil
+}
+
+// extractDomain renvoie l'hôte d'une URL en minuscules, ou une chaîne vide si l'URL est invalide.
+func extractDomain(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
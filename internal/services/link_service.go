@@ -1,11 +1,11 @@
 package services
 
 import (
-	"crypto/rand"
 	"errors"
 	"fmt"
 	"log"
-	"math/big"
+	"net/http"
+	"net/url"
 	"time"
 
 	"gorm.io/gorm" // Nécessaire pour la gestion spécifique de gorm.ErrRecordNotFound
@@ -14,123 +14,461 @@ import (
 	"github.com/axellelanca/urlshortener/internal/repository" // Importe le package repository
 )
 
-// Définition du jeu de caractères pour la génération des codes courts.
-const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-
-
 type LinkService struct {
-	linkRepo repository.LinkRepository // Référence vers le repository de liens
+	linkRepo  repository.LinkRepository     // Référence vers le repository de liens
+	generator CodeGenerator                 // Stratégie de génération des codes courts
+	lengths   *lengthTracker                // Longueur des codes générés et suivi des collisions
+	canonical *URLCanonicalizer             // Mise en forme canonique des URLs longues
+	policy    *DestinationPolicy            // Politique de domaines des destinations, optionnelle
+	reserved  *ReservedWords                // Codes qui ne peuvent être ni générés ni réservés
+	redirect  RedirectDefaults              // Statut et en-têtes de redirection globaux
+	guard     *linkGuard                    // Jetons d'accès et limitation des tentatives des liens protégés
+	rules     *ruleCache                    // Conditions compilées des règles de redirection
+	reuse     *keyedMutex                   // Sérialise FindOrCreateLink par destination canonique
+	clock     func() time.Time              // Horloge des évaluations dépendant de l'heure, time.Now si nil
+	metadata  chan<- models.MetadataRequest // File des récupérations d'informations de destination, optionnelle
 }
 
-// NewLinkService crée et retourne une nouvelle instance de LinkService.
-func NewLinkService(linkRepo repository.LinkRepository) *LinkService {
-	return &LinkService{
-		linkRepo: linkRepo,
+// LinkServiceOption permet de personnaliser un LinkService lors de sa création.
+type LinkServiceOption func(*LinkService)
+
+// WithCodeGenerator remplace la stratégie de génération des codes courts et leur longueur.
+func WithCodeGenerator(generator CodeGenerator, length int) LinkServiceOption {
+	return func(s *LinkService) {
+		s.generator = generator
+		if length > 0 {
+			s.lengths.length = length
+		}
 	}
 }
 
-
-const shortCodeLength = 6 // Longueur du code court
-func GenerateShortCode(length int) (string, error) {
-	if length <= 0 {
-		return "", errors.New("length must be greater than 0")
+// WithURLCanonicalizer remplace le canonicaliseur appliqué aux URLs longues.
+func WithURLCanonicalizer(canonicalizer *URLCanonicalizer) LinkServiceOption {
+	return func(s *LinkService) {
+		s.canonical = canonicalizer
 	}
+}
 
-	code := make([]byte, length)
-	for i := range code {
-		index, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
-		if err != nil {
-			return "", fmt.Errorf("error generating random index: %w", err)
-		}
-		code[i] = charset[index.Int64()]
+// NewLinkService crée et retourne une nouvelle instance de LinkService.
+// Par défaut, les codes courts sont générés aléatoirement sur 6 caractères alphanumériques.
+func NewLinkService(linkRepo repository.LinkRepository, opts ...LinkServiceOption) *LinkService {
+	s := &LinkService{
+		linkRepo:  linkRepo,
+		generator: &RandomCodeGenerator{alphabet: Base62Alphabet},
+		lengths:   newLengthTracker(shortCodeLength),
+		canonical: NewURLCanonicalizer(DefaultTrackingParams),
+		reserved:  defaultReservedWords,
+		redirect:  RedirectDefaults{Status: defaultRedirectStatus, PermanentMaxAge: defaultPermanentMaxAge},
+		guard:     newLinkGuard("", defaultAccessTTL, defaultMaxAttempts, defaultAttemptWindow),
+		rules:     newRuleCache(),
+		reuse:     newKeyedMutex(),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
+}
 
-	return string(code), nil
+const shortCodeLength = 6 // Longueur par défaut du code court
+
+// maxRetries est le nombre maximal de tentatives d'insertion d'un code généré avant abandon.
+const maxRetries = 5
+
+// CreateLinkOptions regroupe les paramètres optionnels de création d'un lien.
+type CreateLinkOptions struct {
+	Alias     string     // Alias personnalisé. S'il est vide, un code court est généré.
+	ExpiresAt *time.Time // Date d'expiration optionnelle
+	MaxClicks *int       // Nombre maximal de redirections optionnel
+	Once      bool       // Lien à usage unique : la première redirection le consomme
+	// Statut de redirection (301, 302, 307 ou 308) et en-têtes de réponse propres au lien.
+	// Un statut nul et des en-têtes absents reprennent les réglages globaux.
+	RedirectStatus int
+	Headers        map[string]string
+	// Transfert de la query string (avec sa politique de conflit) et du chemin suivant le code court.
+	ForwardQuery  bool
+	QueryConflict string
+	ForwardPath   bool
+	// Mot de passe demandé avant la redirection, stocké sous forme d'empreinte bcrypt.
+	Password string
+	// Programmation : date d'activation, plage de disponibilité récurrente et URL servie
+	// lorsque le lien n'est pas disponible.
+	ActivateAt   *time.Time
+	Availability *models.AvailabilityWindow
+	FallbackURL  string
+	// Destinations pondérées d'un test A/B. L'URL longue doit alors être vide : c'est la première variante.
+	// StickyVariants sert toujours la même variante à un visiteur.
+	Variants       []models.LinkVariant
+	StickyVariants bool
+	// Règles de ciblage par appareil, évaluées dans l'ordre ; sans correspondance, la destination
+	// par défaut (URL longue ou variantes) est servie.
+	Targeting []models.TargetingRule
+	// Destinations par étiquette de langue, choisies d'après l'en-tête Accept-Language du visiteur.
+	Localized map[string]string
+	// Destinations par plage horaire (jours, heures, fuseau), évaluées dans l'ordre.
+	TimeRules []models.TimeRule
+	// Règles de redirection "condition → destination", évaluées dans l'ordre avant toutes les autres.
+	RedirectRules []models.RedirectRule
 }
 
-// CreateLink crée un nouveau lien raccourci.
-// Il génère un code court unique, puis persiste le lien dans la base de données.
-func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
-	// TODO 1: Implémenter la logique de retry pour générer un code court unique.
-	// Essayez de générer un code, vérifiez s'il existe déjà en base, et retentez si une collision est trouvée.
-	// Limitez le nombre de tentatives pour éviter une boucle infinie.
+// requiresDedicatedLink indique si les options demandent un lien propre, qui ne peut pas être
+// partagé avec un autre lien vers la même destination.
+func (opts CreateLinkOptions) requiresDedicatedLink() bool {
+	return opts.Alias != "" || opts.ExpiresAt != nil || opts.MaxClicks != nil || opts.Once ||
+		opts.RedirectStatus != 0 || len(opts.Headers) > 0 ||
+		opts.ForwardQuery || opts.QueryConflict != "" || opts.ForwardPath || opts.Password != "" ||
+		opts.ActivateAt != nil || opts.Availability != nil || opts.FallbackURL != "" ||
+		len(opts.Variants) > 0 || opts.StickyVariants || len(opts.Targeting) > 0 || len(opts.Localized) > 0 ||
+		len(opts.TimeRules) > 0 || len(opts.RedirectRules) > 0
+}
 
+// CreateLink crée un nouveau lien raccourci.
+// L'URL longue est d'abord mise sous forme canonique : c'est cette forme qui est enregistrée.
+// Sa destination doit ensuite être autorisée par la politique de domaines, si elle est configurée.
+// Si un alias est fourni, il est validé puis réservé tel quel ; sinon un code court
+// unique est généré. Le lien est ensuite persisté dans la base de données.
+func (s *LinkService) CreateLink(longURL string, opts CreateLinkOptions) (*models.Link, error) {
+	var err error
+	if len(opts.Variants) > 0 {
+		if longURL != "" {
+			return nil, fmt.Errorf("%w: long_url must be empty when variants are given", ErrInvalidVariants)
+		}
+		if opts.Variants, err = s.prepareVariants(opts.Variants); err != nil {
+			return nil, err
+		}
+		longURL = opts.Variants[0].URL
+	} else if longURL, err = s.prepareDestination(longURL); err != nil {
+		return nil, err
+	}
 
+	if err := validateExpiration(opts.ExpiresAt, opts.MaxClicks, opts.Once, s.now()); err != nil {
+		return nil, err
+	}
+	if err := validateRedirect(opts.RedirectStatus, opts.Headers); err != nil {
+		return nil, err
+	}
+	if err := validateQueryConflict(opts.QueryConflict); err != nil {
+		return nil, err
+	}
+	if err := validateSchedule(opts.ActivateAt, opts.ExpiresAt, opts.Availability); err != nil {
+		return nil, err
+	}
+	if opts.FallbackURL != "" {
+		if opts.FallbackURL, err = s.prepareDestination(opts.FallbackURL); err != nil {
+			return nil, err
+		}
+	}
+	if len(opts.Targeting) > 0 {
+		if opts.Targeting, err = s.prepareTargeting(opts.Targeting); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Localized, err = s.prepareLocalized(opts.Localized); err != nil {
+		return nil, err
+	}
+	if len(opts.TimeRules) > 0 {
+		if opts.TimeRules, err = s.prepareTimeRules(opts.TimeRules); err != nil {
+			return nil, err
+		}
+	}
+	if len(opts.RedirectRules) > 0 {
+		if opts.RedirectRules, err = s.prepareRedirectRules(opts.RedirectRules); err != nil {
+			return nil, err
+		}
+	}
 
-	const maxRetries = 5
-	var shortCode string
+	var passwordHash string
+	if opts.Password != "" {
+		if passwordHash, err = hashPassword(opts.Password); err != nil {
+			return nil, err
+		}
+	}
 
-	for i := 0; i < maxRetries; i++ {
+	if opts.Alias != "" {
+		return s.createLinkWithAlias(longURL, passwordHash, opts)
+	}
 
-		code, err := GenerateShortCode(shortCodeLength)
+	// Le code n'est pas vérifié avant l'insertion : l'index unique de la table garantit
+	// qu'un même code ne peut être réservé qu'une fois, même sous des créations concurrentes.
+	// En cas de collision, un nouveau code est généré et l'insertion est retentée. Seules les collisions
+	// des codes aléatoires mesurent le remplissage de l'espace des codes et peuvent les allonger.
+	_, random := s.generator.(randomized)
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		length := s.codeLength()
+		code, err := s.generateCode(longURL, length, attempt)
 		if err != nil {
 			return nil, fmt.Errorf("error generating short code: %w", err)
 		}
+		if s.reserved.IsReserved(code) {
+			// Un code réservé n'est pas une collision : il ne compte pas dans le taux de collisions.
+			log.Printf("Generated short code '%s' is reserved, retrying generation (%d/%d)...", code, attempt+1, maxRetries)
+			continue
+		}
 
-	
+		link := newLink(longURL, code, passwordHash, opts)
+		err = s.linkRepo.CreateLink(link)
+		if err == nil {
+			if random {
+				s.lengths.record(false)
+			}
+			s.requestMetadata(link)
+			return link, nil
+		}
+		if !errors.Is(err, repository.ErrCodeConflict) {
+			return nil, fmt.Errorf("error creating link in repository: %w", err)
+		}
+		log.Printf("Short code '%s' already exists, retrying generation (%d/%d)...", code, attempt+1, maxRetries)
+		if !random {
+			continue
+		}
 
-		_, err = s.linkRepo.GetLinkByShortCode(code)
+		// Chaque collision alimente le suivi du taux de collisions, qui peut allonger les codes
+		// dès la tentative suivante. Si les tentatives sont épuisées à cette longueur, les codes
+		// sont allongés sans attendre et la création reprend, plutôt que d'échouer.
+		s.lengths.record(true)
+		if attempt == maxRetries-1 && s.lengths.grow(length) {
+			attempt = -1
+		}
+	}
+
+	return nil, fmt.Errorf("failed to generate a unique short code after %d attempts: %w", maxRetries, repository.ErrCodeConflict)
+}
 
+// FindOrCreateLink renvoie un lien existant vers la même destination s'il en existe un réutilisable,
+// et en crée un nouveau sinon. Le booléen indique si un nouveau lien a été créé.
+// Un alias, des limites d'expiration ou des réglages de redirection ou de transfert demandent
+// un lien dédié : dans ce cas, un lien est toujours créé.
+// Tant que les liens n'ont pas de propriétaire, la recherche porte uniquement sur la destination.
+// Les appels simultanés pour une même destination sont sérialisés dans le processus : un seul
+// crée le lien, les autres le retrouvent.
+func (s *LinkService) FindOrCreateLink(longURL string, opts CreateLinkOptions) (*models.Link, bool, error) {
+	if !opts.requiresDedicatedLink() {
+		// La recherche porte sur la forme canonique, celle qui est enregistrée en base.
+		canonical, err := s.prepareDestination(longURL)
 		if err != nil {
-			// Si l'erreur est 'record not found' de GORM, cela signifie que le code est unique.
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				shortCode = code // Le code est unique, on peut l'utiliser
-				break            // Sort de la boucle de retry
-			}
-			// Si c'est une autre erreur de base de données, retourne l'erreur.
-			return nil, fmt.Errorf("database error checking short code uniqueness: %w", err)
+			return nil, false, err
+		}
+		longURL = canonical
+
+		// Sans verrou, deux requêtes pourraient ne trouver aucun lien et en créer chacune un.
+		unlock := s.reuse.Lock(longURL)
+		defer unlock()
+
+		link, err := s.linkRepo.FindReusableLink(longURL)
+		if err == nil {
+			return link, false, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, fmt.Errorf("error looking up existing link: %w", err)
 		}
+	}
 
-		// Si aucune erreur (le code a été trouvé), cela signifie une collision.
-		log.Printf("Short code '%s' already exists, retrying generation (%d/%d)...", code, i+1, maxRetries)
-		// La boucle continuera pour générer un nouveau code.
+	link, err := s.CreateLink(longURL, opts)
+	if err != nil {
+		return nil, false, err
 	}
+	return link, true, nil
+}
 
+// prepareDestination met une URL longue sous forme canonique et vérifie que la politique
+// de domaines autorise sa destination.
+func (s *LinkService) prepareDestination(longURL string) (string, error) {
+	longURL, err := s.canonical.Canonicalize(longURL)
+	if err != nil {
+		return "", err
+	}
+	if s.policy == nil {
+		return longURL, nil
+	}
 
-	if shortCode == "" {
-		return nil, errors.New("failed to generate a unique short code after maximum retries")
+	u, err := url.Parse(longURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	if err := s.policy.Check(u); err != nil {
+		return "", err
 	}
+	return longURL, nil
+}
 
+// generateCode produit un code candidat de la longueur donnée avec la stratégie configurée.
+// Les générateurs séquentiels reçoivent l'ID que portera le prochain lien.
+func (s *LinkService) generateCode(longURL string, length, attempt int) (string, error) {
+	req := CodeRequest{
+		Length:  length,
+		LongURL: longURL,
+		Attempt: attempt,
+	}
+
+	if _, ok := s.generator.(sequenceBased); ok {
+		nextID, err := s.linkRepo.NextLinkID()
+		if err != nil {
+			return "", fmt.Errorf("error retrieving next link ID: %w", err)
+		}
+		req.Sequence = uint64(nextID)
+	}
+
+	return s.generator.Generate(req)
+}
+
+// newLink construit le modèle d'un lien à partir de ses paramètres de création.
+// Les dates d'expiration et d'activation sont stockées en UTC pour que les comparaisons en base restent cohérentes.
+func newLink(longURL, shortCode, passwordHash string, opts CreateLinkOptions) *models.Link {
 	link := &models.Link{
 		LongURL:   longURL,
+		Domain:    extractDomain(longURL),
 		Shortcode: shortCode,
-		CreatedAt: time.Now(),
+		CreatedAt: time.Now().UTC(),
+		MaxClicks: opts.MaxClicks,
+		SingleUse: opts.Once,
+
+		RedirectStatus:  opts.RedirectStatus,
+		ResponseHeaders: canonicalHeaders(opts.Headers),
+
+		ForwardQuery:  opts.ForwardQuery,
+		QueryConflict: opts.QueryConflict,
+		ForwardPath:   opts.ForwardPath,
+
+		PasswordHash: passwordHash,
+
+		Availability: opts.Availability,
+		FallbackURL:  opts.FallbackURL,
+
+		Variants:       opts.Variants,
+		StickyVariants: opts.StickyVariants,
+
+		TargetingRules: opts.Targeting,
+		Localized:      opts.Localized,
+		TimeRules:      opts.TimeRules,
+		RedirectRules:  opts.RedirectRules,
 	}
+	if opts.ExpiresAt != nil {
+		expiresAt := opts.ExpiresAt.UTC()
+		link.ExpiresAt = &expiresAt
+	}
+	if opts.ActivateAt != nil {
+		activateAt := opts.ActivateAt.UTC()
+		link.ActivateAt = &activateAt
+	}
+	return link
+}
+
+// createLinkWithAlias réserve un alias personnalisé pour l'URL longue donnée.
+// La réservation repose sur l'index unique de la table : il n'y a pas de vérification
+// préalable, un alias déjà pris est détecté au moment de l'insertion.
+func (s *LinkService) createLinkWithAlias(longURL, passwordHash string, opts CreateLinkOptions) (*models.Link, error) {
+	alias := opts.Alias
+	if err := ValidateAlias(alias); err != nil {
+		return nil, err
+	}
+	if err := s.checkReserved(alias); err != nil {
+		return nil, err
+	}
+
+	link := newLink(longURL, alias, passwordHash, opts)
 
 	if err := s.linkRepo.CreateLink(link); err != nil {
+		if errors.Is(err, repository.ErrCodeConflict) {
+			return nil, fmt.Errorf("%w: '%s'", ErrAliasTaken, alias)
+		}
 		return nil, fmt.Errorf("error creating link in repository: %w", err)
 	}
+	s.requestMetadata(link)
 
 	return link, nil
 }
 
 // GetLinkByShortCode récupère un lien via son code court.
-// Il délègue l'opération de recherche au repository.
+// Il délègue l'opération de recherche au repository et traduit l'absence de résultat en ErrLinkNotFound.
 func (s *LinkService) GetLinkByShortCode(shortCode string) (*models.Link, error) {
-	
 	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("link with shortcode '%s' not found", shortCode)
+			return nil, fmt.Errorf("%w: '%s'", ErrLinkNotFound, shortCode)
 		}
 		return nil, fmt.Errorf("error retrieving link: %w", err)
 	}
 	return link, nil
 }
 
+// Visit décrit la visite d'un lien court : ce qui suit le code court dans l'URL demandée.
+type Visit struct {
+	Path  string     // Chemin qui suit le code court (ex: "/docs/page"), vide s'il n'y en a pas
+	Query url.Values // Paramètres de la query string
+	// Jeton d'accès présenté par le visiteur, délivré par UnlockLink pour les liens protégés.
+	AccessToken string
+	// Variante déjà servie à ce visiteur, conservée si le lien est "sticky".
+	StickyVariant string
+	// En-tête User-Agent du visiteur, analysé pour les règles de ciblage.
+	UserAgent string
+	// En-tête Accept-Language du visiteur, négocié pour les liens localisés.
+	AcceptLanguage string
+	// En-têtes, cookies et adresse IP du visiteur, sur lesquels portent les règles de redirection.
+	Header   http.Header
+	Cookies  map[string]string
+	ClientIP string
+}
+
+// Resolution est le résultat de la résolution d'une visite : le lien, l'URL vers laquelle rediriger
+// et, selon le lien, la variante servie ou la langue négociée.
+type Resolution struct {
+	Link        *models.Link
+	Destination string
+	Variant     string
+	Language    string
+}
+
+// ResolveLink récupère le lien à servir pour une redirection, calcule sa destination et décompte la visite.
+// Il renvoie ErrLinkExpired si le lien a dépassé sa date d'expiration ou épuisé son budget de clics,
+// ErrLinkDisabled s'il est désactivé et ErrLinkNotAvailable s'il n'est pas encore disponible.
+// Un lien protégé sans jeton d'accès valide donne ErrPasswordRequired.
+// Le clic n'est décompté qu'une fois la destination validée : une visite refusée n'entame pas le budget.
+func (s *LinkService) ResolveLink(shortCode string, visit Visit) (*Resolution, error) {
+	link, err := s.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if IsLinkExpired(link, now) {
+		return nil, ErrLinkExpired
+	}
+
+	// Lien désactivé, pas encore activé ou hors de sa plage de disponibilité.
+	if err := checkAvailability(link, now); err != nil {
+		return nil, err
+	}
+
+	if IsPasswordProtected(link) && !s.guard.valid(link, visit.AccessToken, now) {
+		return nil, fmt.Errorf("%w: '%s'", ErrPasswordRequired, shortCode)
+	}
+
+	r := s.routeVisit(link, visit, now)
+	destination, err := r.destination(link, visit)
+	if err != nil {
+		return nil, err
+	}
+
+	consumed, err := s.linkRepo.ConsumeClick(link.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error consuming click for link ID %d: %w", link.ID, err)
+	}
+	if !consumed {
+		// Le budget a été épuisé, ou le lien à usage unique consommé, entre la lecture et la mise à jour conditionnelle.
+		return nil, ErrLinkExpired
+	}
+	link.UsedClicks++
+
+	return &Resolution{Link: link, Destination: destination, Variant: r.variant, Language: r.language}, nil
+}
+
 // GetLinkStats récupère les statistiques pour un lien donné (nombre total de clics).
 // Il interagit avec le LinkRepository pour obtenir le lien, puis avec le ClickRepository
 func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, int, error) {
-
-	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+	link, err := s.GetLinkByShortCode(shortCode)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, 0, fmt.Errorf("link with shortcode '%s' not found", shortCode)
-		}
-		return nil, 0, fmt.Errorf("error retrieving link: %w", err)
+		return nil, 0, err
 	}
 
-
 	clickCount, err := s.linkRepo.CountClicksByLinkID(link.ID)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error counting clicks for link ID %d: %w", link.ID, err)
@@ -139,3 +477,184 @@ func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, int, error)
 	return link, clickCount, nil
 }
 
+// ListLinks renvoie une page de liens selon les critères de pagination, de filtrage et de tri demandés.
+func (s *LinkService) ListLinks(query ListLinksQuery) (*LinkPage, error) {
+	filter, err := query.toFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	links, total, err := s.linkRepo.ListLinks(filter)
+	if err != nil {
+		return nil, fmt.Errorf("error listing links: %w", err)
+	}
+
+	return &LinkPage{
+		Links:  links,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
+}
+
+// UpdateLink applique des modifications partielles à un lien existant.
+// Seuls les champs non nil de l'update sont modifiés.
+func (s *LinkService) UpdateLink(shortCode string, update LinkUpdate) (*models.Link, error) {
+	link, err := s.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, err
+	}
+	previousURL := link.LongURL
+
+	if update.Variants != nil {
+		link.Variants = nil
+		if len(*update.Variants) > 0 {
+			if link.Variants, err = s.prepareVariants(*update.Variants); err != nil {
+				return nil, err
+			}
+			link.LongURL = link.Variants[0].URL
+			link.Domain = extractDomain(link.LongURL)
+		}
+	}
+	if update.StickyVariants != nil {
+		link.StickyVariants = *update.StickyVariants
+	}
+	if update.Localized != nil {
+		if link.Localized, err = s.prepareLocalized(*update.Localized); err != nil {
+			return nil, err
+		}
+	}
+	if update.RedirectRules != nil {
+		link.RedirectRules = nil
+		if len(*update.RedirectRules) > 0 {
+			if link.RedirectRules, err = s.prepareRedirectRules(*update.RedirectRules); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if update.TimeRules != nil {
+		link.TimeRules = nil
+		if len(*update.TimeRules) > 0 {
+			if link.TimeRules, err = s.prepareTimeRules(*update.TimeRules); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if update.Targeting != nil {
+		link.TargetingRules = nil
+		if len(*update.Targeting) > 0 {
+			if link.TargetingRules, err = s.prepareTargeting(*update.Targeting); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if update.LongURL != nil {
+		if len(link.Variants) > 0 {
+			return nil, fmt.Errorf("%w: long_url cannot be changed while the link has variants", ErrInvalidVariants)
+		}
+		longURL, err := s.prepareDestination(*update.LongURL)
+		if err != nil {
+			return nil, err
+		}
+		link.LongURL = longURL
+		link.Domain = extractDomain(longURL)
+	}
+
+	if update.RedirectStatus != nil {
+		if err := validateRedirect(*update.RedirectStatus, nil); err != nil {
+			return nil, err
+		}
+		link.RedirectStatus = *update.RedirectStatus
+	}
+
+	if update.Headers != nil {
+		if err := validateRedirectHeaders(*update.Headers); err != nil {
+			return nil, err
+		}
+		link.ResponseHeaders = canonicalHeaders(*update.Headers)
+	}
+
+	if update.ForwardQuery != nil {
+		link.ForwardQuery = *update.ForwardQuery
+	}
+	if update.QueryConflict != nil {
+		if err := validateQueryConflict(*update.QueryConflict); err != nil {
+			return nil, err
+		}
+		link.QueryConflict = *update.QueryConflict
+	}
+	if update.ForwardPath != nil {
+		link.ForwardPath = *update.ForwardPath
+	}
+
+	if update.ActivateAt != nil || update.Availability != nil {
+		if update.ActivateAt != nil {
+			activateAt := update.ActivateAt.UTC()
+			link.ActivateAt = &activateAt
+		}
+		if update.Availability != nil {
+			link.Availability = update.Availability
+			if update.Availability.Start == "" && update.Availability.End == "" && len(update.Availability.Days) == 0 {
+				link.Availability = nil
+			}
+		}
+		if err := validateSchedule(link.ActivateAt, link.ExpiresAt, link.Availability); err != nil {
+			return nil, err
+		}
+	}
+	if update.FallbackURL != nil {
+		link.FallbackURL = ""
+		if *update.FallbackURL != "" {
+			if link.FallbackURL, err = s.prepareDestination(*update.FallbackURL); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if update.Disabled != nil {
+		link.Disabled = *update.Disabled
+	}
+
+	if update.Password != nil {
+		link.PasswordHash = ""
+		if *update.Password != "" {
+			if link.PasswordHash, err = hashPassword(*update.Password); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Les informations de l'ancienne destination ne la décrivent plus : elles sont récupérées à nouveau.
+	destinationChanged := link.LongURL != previousURL
+	if destinationChanged {
+		link.Metadata = nil
+		link.MetadataNextFetch = nil
+	}
+
+	if err := s.linkRepo.UpdateLink(link); err != nil {
+		return nil, fmt.Errorf("error updating link in repository: %w", err)
+	}
+	if destinationChanged {
+		s.requestMetadata(link)
+	}
+
+	return link, nil
+}
+
+// DeleteLink supprime un lien et ses clics.
+func (s *LinkService) DeleteLink(shortCode string) error {
+	link, err := s.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return err
+	}
+
+	if err := s.linkRepo.DeleteLink(link.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Le lien a été supprimé entre la lecture et la suppression.
+			return fmt.Errorf("%w: '%s'", ErrLinkNotFound, shortCode)
+		}
+		return fmt.Errorf("error deleting link ID %d: %w", link.ID, err)
+	}
+
+	return nil
+}
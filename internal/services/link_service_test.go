@@ -0,0 +1,262 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// stressWorkers est le nombre de créations lancées en parallèle par les tests de concurrence.
+const stressWorkers = 32
+
+// newTestDB ouvre une base SQLite migrée dans un dossier temporaire. Le délai d'attente sur verrou
+// laisse les écritures concurrentes se succéder au lieu d'échouer avec "database is locked".
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(filepath.Join(t.TempDir(), "links.db") + "?_busy_timeout=10000")
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	db.Logger = logger.Discard // Les collisions attendues rempliraient la sortie des tests
+	if err := db.AutoMigrate(&models.Link{}, &models.Click{}); err != nil {
+		t.Fatalf("migrating database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("retrieving sql.DB: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// runConcurrently appelle create depuis n goroutines démarrées ensemble et renvoie les liens
+// créés et les erreurs obtenues.
+func runConcurrently(n int, create func(i int) (*models.Link, error)) ([]*models.Link, []error) {
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		links []*models.Link
+		errs  []error
+	)
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			link, err := create(i)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			links = append(links, link)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return links, errs
+}
+
+// countByCode compte les lignes de la table links portant chaque code court.
+func countByCode(t *testing.T, db *gorm.DB) map[string]int64 {
+	t.Helper()
+	var rows []struct {
+		Shortcode string
+		Count     int64
+	}
+	if err := db.Model(&models.Link{}).Select("shortcode, COUNT(*) AS count").Group("shortcode").Scan(&rows).Error; err != nil {
+		t.Fatalf("counting links: %v", err)
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Shortcode] = row.Count
+	}
+	return counts
+}
+
+// assertPersisted vérifie que chaque lien annoncé comme créé existe bien en base, une seule fois.
+func assertPersisted(t *testing.T, db *gorm.DB, links []*models.Link) {
+	t.Helper()
+	counts := countByCode(t, db)
+	var total int64
+	for _, n := range counts {
+		total += n
+	}
+	if total != int64(len(links)) {
+		t.Errorf("%d rows in database, %d links reported as created", total, len(links))
+	}
+	for _, link := range links {
+		if counts[link.Shortcode] != 1 {
+			t.Errorf("code %q: %d rows, want 1", link.Shortcode, counts[link.Shortcode])
+		}
+		var stored models.Link
+		if err := db.First(&stored, link.ID).Error; err != nil {
+			t.Errorf("link %q reported as created but not found by ID %d: %v", link.Shortcode, link.ID, err)
+			continue
+		}
+		if stored.Shortcode != link.Shortcode || stored.LongURL != link.LongURL {
+			t.Errorf("link %d stored as %q → %q, reported as %q → %q",
+				link.ID, stored.Shortcode, stored.LongURL, link.Shortcode, link.LongURL)
+		}
+	}
+}
+
+func TestRepositoryCreateLinkConcurrentSameCode(t *testing.T) {
+	db := newTestDB(t)
+	repo := repository.NewLinkRepository(db)
+
+	links, errs := runConcurrently(stressWorkers, func(i int) (*models.Link, error) {
+		link := &models.Link{Shortcode: "same", LongURL: fmt.Sprintf("https://example.com/%d", i)}
+		return link, repo.CreateLink(link)
+	})
+
+	if len(links) != 1 {
+		t.Fatalf("%d inserts succeeded, want exactly 1", len(links))
+	}
+	for _, err := range errs {
+		if !errors.Is(err, repository.ErrCodeConflict) {
+			t.Errorf("error = %v, want ErrCodeConflict", err)
+		}
+	}
+	assertPersisted(t, db, links)
+}
+
+func TestCreateLinkConcurrentSameAlias(t *testing.T) {
+	db := newTestDB(t)
+	service := NewLinkService(repository.NewLinkRepository(db))
+
+	links, errs := runConcurrently(stressWorkers, func(i int) (*models.Link, error) {
+		return service.CreateLink(fmt.Sprintf("https://example.com/%d", i), CreateLinkOptions{Alias: "launch"})
+	})
+
+	if len(links) != 1 {
+		t.Fatalf("%d creations succeeded, want exactly 1", len(links))
+	}
+	for _, err := range errs {
+		if !errors.Is(err, ErrAliasTaken) || !errors.Is(err, ErrConflict) {
+			t.Errorf("error = %v, want ErrAliasTaken", err)
+		}
+	}
+	assertPersisted(t, db, links)
+}
+
+func TestCreateLinkConcurrentGeneratedCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		alphabet string
+		length   int
+		// maxLinks est le nombre de codes disponibles lorsqu'il est inférieur au nombre de créations :
+		// les créations en trop doivent échouer, toujours avec ErrCodeConflict. 0 si toutes doivent réussir.
+		maxLinks int
+	}{
+		{name: "random, large keyspace", strategy: StrategyRandom, alphabet: "base62", length: 6},
+		{name: "random, tiny keyspace", strategy: StrategyRandom, alphabet: "ab", length: 3, maxLinks: 8},
+		{name: "sequential", strategy: StrategySequential, alphabet: "base62", length: 4},
+		// Une même URL n'a qu'un code par tentative.
+		{name: "hash, same URL", strategy: StrategyHash, alphabet: "base62", length: 6, maxLinks: maxRetries},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			generator, err := NewCodeGenerator(tt.strategy, tt.alphabet)
+			if err != nil {
+				t.Fatalf("NewCodeGenerator: %v", err)
+			}
+			service := NewLinkService(repository.NewLinkRepository(db),
+				WithCodeGenerator(generator, tt.length),
+				WithReservedWords(NewReservedWords(nil, nil, nil)))
+
+			links, errs := runConcurrently(stressWorkers, func(i int) (*models.Link, error) {
+				longURL := fmt.Sprintf("https://example.com/%d", i)
+				if tt.strategy == StrategyHash {
+					longURL = "https://example.com/same" // Même empreinte à la première tentative
+				}
+				return service.CreateLink(longURL, CreateLinkOptions{})
+			})
+
+			for _, err := range errs {
+				if !errors.Is(err, repository.ErrCodeConflict) {
+					t.Errorf("error = %v, want ErrCodeConflict", err)
+				}
+			}
+			if tt.maxLinks == 0 && len(errs) > 0 {
+				t.Errorf("%d creations failed, want none", len(errs))
+			}
+			if tt.maxLinks > 0 && len(links) > tt.maxLinks {
+				t.Errorf("%d links created, only %d codes available", len(links), tt.maxLinks)
+			}
+			assertPersisted(t, db, links)
+		})
+	}
+}
+
+// slowLookupRepository attend après une recherche de lien réutilisable infructueuse, pour que
+// les appels concurrents se trouvent tous entre la recherche et la création.
+type slowLookupRepository struct {
+	repository.LinkRepository
+}
+
+func (r slowLookupRepository) FindReusableLink(longURL string) (*models.Link, error) {
+	link, err := r.LinkRepository.FindReusableLink(longURL)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	return link, err
+}
+
+func TestFindOrCreateLinkConcurrentSameURL(t *testing.T) {
+	db := newTestDB(t)
+	service := NewLinkService(slowLookupRepository{repository.NewLinkRepository(db)})
+
+	var mu sync.Mutex
+	created := 0
+	links, errs := runConcurrently(stressWorkers, func(i int) (*models.Link, error) {
+		// Des écritures différentes d'une même destination canonique.
+		longURL := "https://example.com/page"
+		if i%2 == 1 {
+			longURL = fmt.Sprintf("HTTPS://Example.com/page?utm_source=%d", i)
+		}
+		link, isNew, err := service.FindOrCreateLink(longURL, CreateLinkOptions{})
+		if isNew {
+			mu.Lock()
+			created++
+			mu.Unlock()
+		}
+		return link, err
+	})
+
+	for _, err := range errs {
+		t.Errorf("FindOrCreateLink: %v", err)
+	}
+	if created != 1 {
+		t.Errorf("%d links created, want 1", created)
+	}
+	for _, link := range links {
+		if link.ID != links[0].ID {
+			t.Errorf("link %d (%q) returned, want the single link %d", link.ID, link.Shortcode, links[0].ID)
+		}
+	}
+	var rows int64
+	if err := db.Model(&models.Link{}).Count(&rows).Error; err != nil {
+		t.Fatalf("counting links: %v", err)
+	}
+	if rows != 1 {
+		t.Errorf("%d rows in database, want 1", rows)
+	}
+	if len(service.reuse.locks) != 0 {
+		t.Errorf("%d destination locks left after the calls returned", len(service.reuse.locks))
+	}
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"log"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// WithMetadataQueue transmet les liens créés, ou dont la destination change, à la file des
+// récupérations d'informations de destination (titre, description, image, icône).
+// Sans file, les informations sont récupérées plus tard par le planificateur du serveur.
+func WithMetadataQueue(queue chan<- models.MetadataRequest) LinkServiceOption {
+	return func(s *LinkService) {
+		s.metadata = queue
+	}
+}
+
+// requestMetadata demande la récupération des informations de la destination d'un lien.
+// L'envoi est non bloquant : la création du lien n'attend pas la récupération, et une file
+// pleine laisse le lien au planificateur.
+func (s *LinkService) requestMetadata(link *models.Link) {
+	if s.metadata == nil {
+		return
+	}
+	select {
+	case s.metadata <- models.MetadataRequest{LinkID: link.ID, URL: link.LongURL, Current: link.Metadata}:
+	default:
+		log.Printf("Metadata queue is full, link '%s' is left to the scheduler.", link.Shortcode)
+	}
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Contraintes et valeurs par défaut de la protection des liens par mot de passe.
+const (
+	passwordMinLength      = 4
+	passwordMaxLength      = 72 // Limite de bcrypt
+	defaultAccessTTL       = 10 * time.Minute
+	defaultMaxAttempts     = 5
+	defaultAttemptWindow   = 15 * time.Minute
+	attemptLimiterSweepAge = time.Hour
+)
+
+// Erreurs liées aux liens protégés par mot de passe
+var (
+	ErrPasswordRequired = newDomainError(ErrUnauthorized, "password_required", "link is password protected")
+	ErrWrongPassword    = newDomainError(ErrUnauthorized, "wrong_password", "password is incorrect")
+	ErrTooManyAttempts  = newDomainError(ErrRateLimited, "too_many_attempts", "too many failed password attempts")
+	ErrInvalidPassword  = newDomainError(ErrInvalidInput, "invalid_password", "password is invalid")
+)
+
+// hashPassword valide un mot de passe de lien et renvoie son empreinte bcrypt.
+func hashPassword(password string) (string, error) {
+	if len(password) < passwordMinLength || len(password) > passwordMaxLength {
+		return "", fmt.Errorf("%w: length must be between %d and %d bytes", ErrInvalidPassword, passwordMinLength, passwordMaxLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("error hashing password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// IsPasswordProtected indique si un lien exige un mot de passe.
+func IsPasswordProtected(link *models.Link) bool {
+	return link.PasswordHash != ""
+}
+
+// linkGuard délivre et vérifie les jetons d'accès aux liens protégés et limite les tentatives échouées.
+// Un jeton est signé (HMAC-SHA256) sur le lien, sa date d'expiration et l'empreinte du mot de passe :
+// changer le mot de passe invalide les jetons déjà délivrés.
+type linkGuard struct {
+	secret  []byte
+	ttl     time.Duration
+	limiter *attemptLimiter
+}
+
+// newLinkGuard crée un garde. Un secret vide est remplacé par un secret aléatoire : les jetons
+// délivrés ne survivent alors pas à un redémarrage.
+func newLinkGuard(secret string, ttl time.Duration, maxAttempts int, window time.Duration) *linkGuard {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("crypto/rand failed: %v", err))
+		}
+	}
+	if ttl <= 0 {
+		ttl = defaultAccessTTL
+	}
+	return &linkGuard{secret: key, ttl: ttl, limiter: newAttemptLimiter(maxAttempts, window)}
+}
+
+// issue délivre un jeton d'accès au lien, valable jusqu'à la date renvoyée.
+func (g *linkGuard) issue(link *models.Link, now time.Time) (string, time.Time) {
+	expiresAt := now.Add(g.ttl)
+	exp := strconv.FormatInt(expiresAt.Unix(), 10)
+	return exp + "." + g.sign(link, exp), expiresAt
+}
+
+// valid vérifie qu'un jeton a été délivré pour ce lien et n'a pas expiré.
+func (g *linkGuard) valid(link *models.Link, token string, now time.Time) bool {
+	exp, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || now.Unix() >= expUnix {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(g.sign(link, exp)))
+}
+
+func (g *linkGuard) sign(link *models.Link, exp string) string {
+	mac := hmac.New(sha256.New, g.secret)
+	fmt.Fprintf(mac, "%d|%s|%s|%s", link.ID, link.Shortcode, exp, link.PasswordHash)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// attemptLimiter compte les tentatives par clé (IP et lien) sur une fenêtre fixe.
+type attemptLimiter struct {
+	mu        sync.Mutex
+	max       int
+	window    time.Duration
+	entries   map[string]*attemptWindow
+	lastSweep time.Time
+}
+
+type attemptWindow struct {
+	start    time.Time
+	attempts int // Tentatives comptées, y compris celles en cours de vérification
+}
+
+func newAttemptLimiter(max int, window time.Duration) *attemptLimiter {
+	if max <= 0 {
+		max = defaultMaxAttempts
+	}
+	if window <= 0 {
+		window = defaultAttemptWindow
+	}
+	return &attemptLimiter{max: max, window: window, entries: make(map[string]*attemptWindow)}
+}
+
+// reserve compte une tentative pour la clé avant sa vérification, ou indique dans combien de temps
+// elle pourra réessayer si ses tentatives sont épuisées. La réservation est atomique : des requêtes
+// simultanées ne peuvent pas vérifier plus de max mots de passe sur la fenêtre.
+func (l *attemptLimiter) reserve(key string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	entry, ok := l.entries[key]
+	if !ok || now.Sub(entry.start) >= l.window {
+		entry = &attemptWindow{start: now}
+		l.entries[key] = entry
+	}
+	if entry.attempts >= l.max {
+		return entry.start.Add(l.window).Sub(now), false
+	}
+	entry.attempts++
+	return 0, true
+}
+
+// reset oublie les tentatives échouées d'une clé après une tentative réussie.
+func (l *attemptLimiter) reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, key)
+}
+
+// sweep retire périodiquement les fenêtres terminées, pour que la table ne grossisse pas indéfiniment.
+func (l *attemptLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < attemptLimiterSweepAge {
+		return
+	}
+	l.lastSweep = now
+	for key, entry := range l.entries {
+		if now.Sub(entry.start) >= l.window {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// PasswordProtection regroupe les réglages de la protection des liens par mot de passe.
+type PasswordProtection struct {
+	CookieSecret  string        // Clé de signature des jetons d'accès ; vide = clé aléatoire
+	AccessTTL     time.Duration // Durée de validité d'un jeton d'accès
+	MaxAttempts   int           // Tentatives échouées autorisées par IP et par lien sur la fenêtre
+	AttemptWindow time.Duration
+}
+
+// WithPasswordProtection remplace les réglages de la protection par mot de passe.
+func WithPasswordProtection(settings PasswordProtection) LinkServiceOption {
+	return func(s *LinkService) {
+		s.guard = newLinkGuard(settings.CookieSecret, settings.AccessTTL, settings.MaxAttempts, settings.AttemptWindow)
+	}
+}
+
+// LinkAccess est un jeton d'accès délivré après la saisie du bon mot de passe.
+type LinkAccess struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// tooManyAttemptsError est l'erreur renvoyée lorsqu'une IP a épuisé ses tentatives sur un lien.
+type tooManyAttemptsError struct {
+	retryAfter time.Duration
+}
+
+func (e *tooManyAttemptsError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", ErrTooManyAttempts.Error(), e.retryAfter.Round(time.Second))
+}
+
+func (e *tooManyAttemptsError) Unwrap() error { return ErrTooManyAttempts }
+
+// RetryDelay renvoie le délai avant une nouvelle tentative porté par une erreur ErrTooManyAttempts.
+func RetryDelay(err error) (time.Duration, bool) {
+	var tooMany *tooManyAttemptsError
+	if errors.As(err, &tooMany) {
+		return tooMany.retryAfter, true
+	}
+	return 0, false
+}
+
+// UnlockLink vérifie le mot de passe d'un lien protégé et délivre un jeton d'accès.
+// Les tentatives échouées sont limitées par IP et par lien ; au-delà, ErrTooManyAttempts est renvoyée
+// sans même vérifier le mot de passe.
+func (s *LinkService) UnlockLink(shortCode, password, clientIP string) (*LinkAccess, error) {
+	link, err := s.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if !IsPasswordProtected(link) {
+		return nil, fmt.Errorf("%w: link '%s' has no password", ErrInvalidPassword, shortCode)
+	}
+
+	now := time.Now()
+	key := clientIP + "|" + strconv.FormatUint(uint64(link.ID), 10)
+	// La tentative est comptée avant la comparaison bcrypt, lente : elle n'est oubliée qu'en cas de succès.
+	if wait, ok := s.guard.limiter.reserve(key, now); !ok {
+		return nil, &tooManyAttemptsError{retryAfter: wait}
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrWrongPassword
+	}
+	s.guard.limiter.reset(key)
+
+	token, expiresAt := s.guard.issue(link, now)
+	return &LinkAccess{Token: token, ExpiresAt: expiresAt}, nil
+}
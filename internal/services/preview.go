@@ -0,0 +1,48 @@
+package services
+
+import (
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// LinkPreview décrit un lien tel qu'il serait servi à un visiteur, sans le rediriger.
+type LinkPreview struct {
+	Link *models.Link
+	// Destination vers laquelle le visiteur serait redirigé, vide lorsqu'elle est retenue.
+	Destination       string
+	State             string // État du lien (active, expired, disabled...)
+	PasswordProtected bool
+	// Withheld indique que la destination n'est pas révélée : lien protégé par mot de passe,
+	// à usage unique ou qui n'est pas servi actuellement.
+	Withheld bool
+}
+
+// PreviewLink calcule la destination qu'une visite obtiendrait, sans décompter de clic ni en
+// enregistrer. Un lien expiré ou indisponible reste prévisualisable : son état est indiqué à part,
+// mais sa destination n'est pas révélée. Celle d'un lien protégé par mot de passe ou à usage unique
+// ne l'est jamais non plus : l'aperçu permettrait de la lire sans saisir le mot de passe ou sans
+// consommer le lien.
+func (s *LinkService) PreviewLink(shortCode string, visit Visit) (*LinkPreview, error) {
+	link, err := s.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	preview := &LinkPreview{
+		Link:              link,
+		State:             LinkState(link, now),
+		PasswordProtected: IsPasswordProtected(link),
+	}
+	preview.Withheld = preview.PasswordProtected || link.SingleUse || preview.State != LinkStateActive
+	if preview.Withheld {
+		return preview, nil
+	}
+
+	r := s.routeVisit(link, visit, now)
+	destination, err := r.destination(link, visit)
+	if err != nil {
+		return nil, err
+	}
+	preview.Destination = destination
+	return preview, nil
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/axellelanca/urlshortener/internal/qrcode"
+)
+
+// Formats d'image des QR codes.
+const (
+	QRFormatPNG = "png"
+	QRFormatSVG = "svg"
+)
+
+// Limites des paramètres des QR codes.
+const (
+	minQRSize   = 21 // Un pixel par module pour la plus petite version, sans marge
+	maxQRMargin = 40
+)
+
+// ErrInvalidQROptions est renvoyée lorsque les paramètres d'un QR code sont invalides.
+var ErrInvalidQROptions = newDomainError(ErrInvalidInput, "invalid_qr_options", "QR code options are invalid")
+
+// QROptions décrit l'image d'un QR code.
+type QROptions struct {
+	Format     string // png ou svg
+	Size       int    // Côté de l'image en pixels, marge comprise
+	Margin     int    // Zone de silence en modules
+	Level      string // Niveau de correction d'erreurs : L, M, Q ou H
+	Foreground string // Couleur des modules sombres (#rgb, #rrggbb ou #rrggbbaa)
+	Background string // Couleur du fond
+}
+
+// DefaultQROptions renvoie les paramètres appliqués en l'absence de précision :
+// PNG noir sur blanc de 256 pixels, marge de 4 modules, correction M.
+func DefaultQROptions() QROptions {
+	return QROptions{
+		Format:     QRFormatPNG,
+		Size:       256,
+		Margin:     4,
+		Level:      "M",
+		Foreground: "#000000",
+		Background: "#ffffff",
+	}
+}
+
+// NegotiateQRFormat choisit le format d'un QR code demandé sans paramètre format : PNG, sauf si
+// l'en-tête Accept donne à image/svg+xml un poids strictement supérieur à celui de image/png.
+// Le poids d'un type est celui de l'entrée la plus précise qui le couvre (image/png, puis image/*,
+// puis */*) ; les entrées mal formées sont ignorées.
+func NegotiateQRFormat(accept string) string {
+	if acceptWeight(accept, "image/svg+xml") > acceptWeight(accept, "image/png") {
+		return QRFormatSVG
+	}
+	return QRFormatPNG
+}
+
+// acceptWeight renvoie le poids qu'un en-tête Accept donne à un type de média, 0 s'il n'est pas couvert.
+func acceptWeight(accept, mediaType string) float64 {
+	major, _, _ := strings.Cut(mediaType, "/")
+	weight, specificity := 0.0, 0
+	for _, entry := range strings.Split(accept, ",") {
+		params := strings.Split(entry, ";")
+		rangeType := strings.ToLower(strings.TrimSpace(params[0]))
+		precision := 0
+		switch rangeType {
+		case mediaType:
+			precision = 3
+		case major + "/*":
+			precision = 2
+		case "*/*":
+			precision = 1
+		}
+		if precision <= specificity {
+			continue
+		}
+
+		q, valid := 1.0, true
+		for _, param := range params[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				parsed, err := strconv.ParseFloat(value, 64)
+				valid = err == nil && parsed >= 0 && parsed <= 1
+				q = parsed
+			}
+		}
+		if valid {
+			weight, specificity = q, precision
+		}
+	}
+	return weight
+}
+
+// QRImage est l'image d'un QR code prête à être servie.
+type QRImage struct {
+	Data        []byte
+	ContentType string
+	ETag        string // Empreinte du contenu et des paramètres, stable d'un démarrage à l'autre
+}
+
+// QRGenerator génère les images de QR code et garde les plus récentes en mémoire, une par
+// contenu et jeu de paramètres.
+type QRGenerator struct {
+	maxSize  int // Côté maximal des images, en pixels
+	capacity int // Nombre d'images gardées en mémoire, 0 pour aucune
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // Du plus récemment servi au plus ancien
+}
+
+// qrCacheEntry est une image du cache, avec sa clé pour l'éviction.
+type qrCacheEntry struct {
+	key   string
+	image *QRImage
+}
+
+// NewQRGenerator crée un générateur qui garde au plus cacheSize images en mémoire et refuse
+// les images de plus de maxSize pixels de côté.
+func NewQRGenerator(cacheSize, maxSize int) *QRGenerator {
+	return &QRGenerator{
+		maxSize:  maxSize,
+		capacity: cacheSize,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// qrParams sont les paramètres validés d'une image.
+type qrParams struct {
+	format string
+	level  qrcode.Level
+	render qrcode.RenderOptions
+}
+
+// validate contrôle les paramètres et les met sous la forme utilisée par l'encodeur.
+func (g *QRGenerator) validate(opts QROptions) (*qrParams, error) {
+	p := &qrParams{format: strings.ToLower(strings.TrimSpace(opts.Format))}
+	if p.format != QRFormatPNG && p.format != QRFormatSVG {
+		return nil, fmt.Errorf("%w: unknown format '%s', use png or svg", ErrInvalidQROptions, opts.Format)
+	}
+	if opts.Size < minQRSize || opts.Size > g.maxSize {
+		return nil, fmt.Errorf("%w: size must be between %d and %d pixels", ErrInvalidQROptions, minQRSize, g.maxSize)
+	}
+	if opts.Margin < 0 || opts.Margin > maxQRMargin {
+		return nil, fmt.Errorf("%w: margin must be between 0 and %d modules", ErrInvalidQROptions, maxQRMargin)
+	}
+	var err error
+	if p.level, err = qrcode.ParseLevel(opts.Level); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQROptions, err)
+	}
+	if p.render.Foreground, err = qrcode.ParseColor(opts.Foreground); err != nil {
+		return nil, fmt.Errorf("%w: foreground: %v", ErrInvalidQROptions, err)
+	}
+	if p.render.Background, err = qrcode.ParseColor(opts.Background); err != nil {
+		return nil, fmt.Errorf("%w: background: %v", ErrInvalidQROptions, err)
+	}
+	p.render.Size = opts.Size
+	p.render.Margin = opts.Margin
+	return p, nil
+}
+
+// key identifie une image : les paramètres équivalents ("#FFF" et "ffffff") partagent la même entrée.
+func (p *qrParams) key(content string) string {
+	return fmt.Sprintf("%s|%s|%d|%d|%s|%s|%s", p.format, p.level, p.render.Size, p.render.Margin,
+		hexColor(p.render.Foreground), hexColor(p.render.Background), content)
+}
+
+func hexColor(c color.NRGBA) string {
+	return fmt.Sprintf("%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+// Generate renvoie l'image du QR code de content avec les paramètres donnés, depuis le cache
+// si elle y est déjà.
+func (g *QRGenerator) Generate(content string, opts QROptions) (*QRImage, error) {
+	params, err := g.validate(opts)
+	if err != nil {
+		return nil, err
+	}
+	key := params.key(content)
+	if image := g.cached(key); image != nil {
+		return image, nil
+	}
+
+	code, err := qrcode.Encode([]byte(content), params.level)
+	if err != nil {
+		if errors.Is(err, qrcode.ErrDataTooLong) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidQROptions, err)
+		}
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	image := &QRImage{ETag: `"` + hex.EncodeToString(sum[:12]) + `"`}
+	switch params.format {
+	case QRFormatSVG:
+		image.Data = code.SVG(params.render)
+		image.ContentType = "image/svg+xml"
+	default:
+		if image.Data, err = code.PNG(params.render); err != nil {
+			// Image trop petite pour la version du symbole.
+			return nil, fmt.Errorf("%w: %v", ErrInvalidQROptions, err)
+		}
+		image.ContentType = "image/png"
+	}
+
+	g.store(key, image)
+	return image, nil
+}
+
+// cached renvoie l'image en cache pour une clé, nil si elle n'y est pas.
+func (g *QRGenerator) cached(key string) *QRImage {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if element, ok := g.entries[key]; ok {
+		g.order.MoveToFront(element)
+		return element.Value.(*qrCacheEntry).image
+	}
+	return nil
+}
+
+// store ajoute une image au cache, en évinçant la moins récemment servie s'il est plein.
+func (g *QRGenerator) store(key string, image *QRImage) {
+	if g.capacity <= 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.entries[key]; ok {
+		return // Générée en parallèle par une autre requête
+	}
+	g.entries[key] = g.order.PushFront(&qrCacheEntry{key: key, image: image})
+	if g.order.Len() > g.capacity {
+		oldest := g.order.Back()
+		g.order.Remove(oldest)
+		delete(g.entries, oldest.Value.(*qrCacheEntry).key)
+	}
+}
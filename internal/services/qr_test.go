@@ -0,0 +1,185 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestQRGeneratorCacheKey(t *testing.T) {
+	generator := NewQRGenerator(100, 1024)
+	base := DefaultQROptions()
+	first, err := generator.Generate("https://sho.rt/abc", base)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	with := func(change func(*QROptions)) QROptions {
+		opts := base
+		change(&opts)
+		return opts
+	}
+	// Paramètres équivalents : même entrée du cache.
+	same := map[string]QROptions{
+		"short colors":      with(func(o *QROptions) { o.Foreground, o.Background = "#000", "#FFF" }),
+		"colors with alpha": with(func(o *QROptions) { o.Foreground, o.Background = "#000000ff", "#ffffffFF" }),
+		"format case":       with(func(o *QROptions) { o.Format = " PNG " }),
+		"level case":        with(func(o *QROptions) { o.Level = "m" }),
+	}
+	for name, opts := range same {
+		image, err := generator.Generate("https://sho.rt/abc", opts)
+		if err != nil {
+			t.Fatalf("%s: Generate: %v", name, err)
+		}
+		if image != first {
+			t.Errorf("%s: image generated again, want the cached one", name)
+		}
+	}
+
+	// Un seul paramètre différent : une autre image, avec une autre empreinte.
+	different := map[string]QROptions{
+		"format":     with(func(o *QROptions) { o.Format = QRFormatSVG }),
+		"size":       with(func(o *QROptions) { o.Size = 512 }),
+		"margin":     with(func(o *QROptions) { o.Margin = 2 }),
+		"level":      with(func(o *QROptions) { o.Level = "H" }),
+		"foreground": with(func(o *QROptions) { o.Foreground = "#112233" }),
+		"background": with(func(o *QROptions) { o.Background = "#ffffff00" }),
+	}
+	etags := map[string]string{first.ETag: "default"}
+	for name, opts := range different {
+		image, err := generator.Generate("https://sho.rt/abc", opts)
+		if err != nil {
+			t.Fatalf("%s: Generate: %v", name, err)
+		}
+		if other, ok := etags[image.ETag]; ok {
+			t.Errorf("%s: same ETag as %s", name, other)
+		}
+		etags[image.ETag] = name
+	}
+	other, err := generator.Generate("https://sho.rt/abd", base)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if other == first || other.ETag == first.ETag {
+		t.Error("another content shares the cached image")
+	}
+}
+
+func TestQRGeneratorEviction(t *testing.T) {
+	generator := NewQRGenerator(2, 1024)
+	opts := DefaultQROptions()
+	generate := func(content string) *QRImage {
+		t.Helper()
+		image, err := generator.Generate(content, opts)
+		if err != nil {
+			t.Fatalf("Generate(%q): %v", content, err)
+		}
+		return image
+	}
+
+	a, b := generate("a"), generate("b")
+	if generate("a") != a {
+		t.Fatal("a is not cached")
+	}
+	// Le cache est plein : c évince b, le moins récemment servi.
+	generate("c")
+	if generate("a") != a {
+		t.Error("a was evicted, want the least recently served image (b) evicted")
+	}
+	if generate("b") == b {
+		t.Error("b is still cached after the eviction")
+	}
+	if len(generator.entries) != 2 || generator.order.Len() != 2 {
+		t.Errorf("cache holds %d entries and %d list elements, want 2", len(generator.entries), generator.order.Len())
+	}
+}
+
+func TestQRGeneratorWithoutCache(t *testing.T) {
+	generator := NewQRGenerator(0, 1024)
+	first, err := generator.Generate("a", DefaultQROptions())
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	second, err := generator.Generate("a", DefaultQROptions())
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if first == second || len(generator.entries) != 0 {
+		t.Error("image cached with a cache size of 0")
+	}
+	if first.ETag != second.ETag {
+		t.Errorf("ETag changed between generations: %s, %s", first.ETag, second.ETag)
+	}
+}
+
+func TestQRGeneratorInvalidOptions(t *testing.T) {
+	generator := NewQRGenerator(10, 1024)
+	with := func(change func(*QROptions)) QROptions {
+		opts := DefaultQROptions()
+		change(&opts)
+		return opts
+	}
+	tests := []struct {
+		name    string
+		content string
+		opts    QROptions
+		wantMsg string
+	}{
+		{"format", "a", with(func(o *QROptions) { o.Format = "gif" }), "unknown format 'gif'"},
+		{"size too small", "a", with(func(o *QROptions) { o.Size = 20 }), "size must be between 21 and 1024"},
+		{"size too large", "a", with(func(o *QROptions) { o.Size = 1025 }), "size must be between 21 and 1024"},
+		{"negative margin", "a", with(func(o *QROptions) { o.Margin = -1 }), "margin must be between 0 and 40"},
+		{"margin too large", "a", with(func(o *QROptions) { o.Margin = 41 }), "margin must be between 0 and 40"},
+		{"level", "a", with(func(o *QROptions) { o.Level = "X" }), "unknown error correction level 'X'"},
+		{"foreground", "a", with(func(o *QROptions) { o.Foreground = "black" }), "foreground"},
+		{"background", "a", with(func(o *QROptions) { o.Background = "#ggg" }), "background"},
+		{"content too long", strings.Repeat("a", 2332), DefaultQROptions(), "data too long"},
+		{"image smaller than the symbol", strings.Repeat("a", 100), with(func(o *QROptions) { o.Size = 30 }), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := generator.Generate(tt.content, tt.opts)
+			if !errors.Is(err, ErrInvalidQROptions) {
+				t.Fatalf("Generate error = %v, want ErrInvalidQROptions", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("Generate error = %v, want %q", err, tt.wantMsg)
+			}
+		})
+	}
+	if len(generator.entries) != 0 {
+		t.Errorf("%d entries cached after invalid requests", len(generator.entries))
+	}
+}
+
+func TestNegotiateQRFormat(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", QRFormatPNG},
+		{"*/*", QRFormatPNG},
+		{"image/svg+xml", QRFormatSVG},
+		{"image/svg+xml, */*;q=0.8", QRFormatSVG},
+		{"IMAGE/SVG+XML", QRFormatSVG},
+		// Navigateurs : SVG accepté au même poids que PNG, ou seulement par image/*.
+		{"image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8", QRFormatPNG},
+		{"image/webp,*/*", QRFormatPNG},
+		{"image/png,image/svg+xml", QRFormatPNG},
+		{"image/svg+xml;q=0.9, image/png", QRFormatPNG},
+		{"image/png;q=0.5, image/svg+xml;q=0.9", QRFormatSVG},
+		{"image/svg+xml, image/*;q=0.5", QRFormatSVG},
+		// L'entrée la plus précise l'emporte.
+		{"image/png;q=0, image/*", QRFormatSVG},
+		{"image/svg+xml;q=0, */*", QRFormatPNG},
+		{"text/html, image/svg+xml;level=1;q=0.5", QRFormatSVG},
+		// Entrées mal formées ignorées.
+		{"image/svg+xml;q=2", QRFormatPNG},
+		{"image/svg+xml;q=abc, image/png;q=0.1", QRFormatPNG},
+	}
+	for _, tt := range tests {
+		if got := NegotiateQRFormat(tt.accept); got != tt.want {
+			t.Errorf("NegotiateQRFormat(%q) = %s, want %s", tt.accept, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// ErrInvalidRedirect est renvoyée lorsque le statut ou les en-têtes de redirection d'un lien sont invalides.
+var ErrInvalidRedirect = newDomainError(ErrInvalidInput, "invalid_redirect", "redirect settings are invalid")
+
+// redirectStatuses liste les statuts de redirection qu'un lien peut utiliser.
+var redirectStatuses = map[int]struct{}{
+	http.StatusMovedPermanently:  {},
+	http.StatusFound:             {},
+	http.StatusTemporaryRedirect: {},
+	http.StatusPermanentRedirect: {},
+}
+
+// redirectHeaders liste les en-têtes de réponse qu'un lien ou l'opérateur peut définir.
+// Les en-têtes qui changeraient le sens de la réponse (Location, Content-Type, Set-Cookie...)
+// ne sont pas modifiables. Les en-têtes d'extension préfixés par "X-" sont également acceptés.
+var redirectHeaders = map[string]struct{}{
+	"Cache-Control":   {},
+	"Expires":         {},
+	"Referrer-Policy": {},
+	"X-Robots-Tag":    {},
+	"Link":            {},
+	"Vary":            {},
+}
+
+// Valeurs par défaut des redirections lorsqu'aucun réglage n'est configuré.
+const (
+	defaultRedirectStatus    = http.StatusFound
+	defaultPermanentMaxAge   = 86400
+	temporaryRedirectCaching = "no-store"
+	privateRedirectCaching   = "private, no-store"
+)
+
+// RedirectDefaults regroupe les réglages de redirection appliqués aux liens qui n'en définissent pas.
+type RedirectDefaults struct {
+	Status          int               // Statut utilisé par les liens sans statut propre
+	Headers         map[string]string // En-têtes ajoutés à toutes les redirections
+	PermanentMaxAge int               // Durée de cache (secondes) des redirections permanentes sans Cache-Control
+}
+
+// WithRedirectDefaults remplace les réglages de redirection globaux du service.
+// Ils sont validés comme ceux d'un lien.
+func WithRedirectDefaults(defaults RedirectDefaults) LinkServiceOption {
+	return func(s *LinkService) {
+		if defaults.Status == 0 {
+			defaults.Status = defaultRedirectStatus
+		}
+		s.redirect = defaults
+	}
+}
+
+// ValidateRedirectDefaults vérifie les réglages de redirection globaux issus de la configuration.
+func ValidateRedirectDefaults(defaults RedirectDefaults) error {
+	if defaults.Status == 0 {
+		return validateRedirectHeaders(defaults.Headers)
+	}
+	return validateRedirect(defaults.Status, defaults.Headers)
+}
+
+// validateRedirect vérifie le statut de redirection (0 = statut global) et les en-têtes d'un lien.
+func validateRedirect(status int, headers map[string]string) error {
+	if _, ok := redirectStatuses[status]; status != 0 && !ok {
+		return fmt.Errorf("%w: status %d is not a redirect status, use 301, 302, 307 or 308", ErrInvalidRedirect, status)
+	}
+	return validateRedirectHeaders(headers)
+}
+
+// validateRedirectHeaders vérifie que les en-têtes sont modifiables et que leurs valeurs tiennent sur une ligne.
+func validateRedirectHeaders(headers map[string]string) error {
+	for name, value := range headers {
+		canonical := http.CanonicalHeaderKey(name)
+		if _, ok := redirectHeaders[canonical]; !ok && !strings.HasPrefix(canonical, "X-") {
+			return fmt.Errorf("%w: header '%s' cannot be set", ErrInvalidRedirect, name)
+		}
+		if strings.ContainsAny(name+value, "\r\n") {
+			return fmt.Errorf("%w: header '%s' contains a line break", ErrInvalidRedirect, name)
+		}
+	}
+	return nil
+}
+
+// canonicalHeaders renvoie les en-têtes avec des noms canoniques, ou nil s'il n'y en a aucun.
+func canonicalHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(headers))
+	for name, value := range headers {
+		result[http.CanonicalHeaderKey(name)] = value
+	}
+	return result
+}
+
+// RedirectResponse décrit la réponse de redirection à servir pour un lien.
+type RedirectResponse struct {
+	Status  int
+	Headers http.Header
+}
+
+// RedirectFor calcule le statut et les en-têtes de la redirection d'un lien.
+// Les en-têtes du lien remplacent les en-têtes globaux de même nom. Sans Cache-Control explicite,
+// les redirections permanentes sont cachables et les redirections temporaires ne le sont pas,
+// pas plus que celles des liens programmés ou à règles. Le cache d'un lien qui expire ne dépasse pas sa durée de vie restante.
+// Les redirections propres à chaque visiteur ne sont jamais mises en cache, même avec un Cache-Control explicite.
+func (s *LinkService) RedirectFor(link *models.Link) RedirectResponse {
+	status := link.RedirectStatus
+	if status == 0 {
+		status = s.redirect.Status
+	}
+
+	headers := make(http.Header)
+	for name, value := range s.redirect.Headers {
+		headers.Set(name, value)
+	}
+	for name, value := range link.ResponseHeaders {
+		headers.Set(name, value)
+	}
+
+	// La destination d'un lien à règles horaires ou de redirection peut changer d'une visite à l'autre
+	// (heure, cookies, adresse IP...) : elle n'est pas mise en cache. Un lien programmé non plus : hors de
+	// sa plage, ou une fois désactivé, le visiteur doit recevoir la réponse d'indisponibilité ou le repli.
+	cacheable := isPermanentRedirect(status) && len(link.TimeRules) == 0 && len(link.RedirectRules) == 0 &&
+		link.ActivateAt == nil && link.Availability == nil && link.FallbackURL == ""
+	maxAge := s.redirect.PermanentMaxAge
+	if cacheable && link.ExpiresAt != nil {
+		// Le cache ne survit pas au lien : une fois expiré, le visiteur doit recevoir 410 Gone.
+		maxAge = min(maxAge, int(link.ExpiresAt.Sub(s.now())/time.Second))
+		cacheable = maxAge > 0
+	}
+	if privateRedirect(link) {
+		headers.Set("Cache-Control", privateRedirectCaching)
+	} else if headers.Get("Cache-Control") == "" {
+		if cacheable && maxAge > 0 {
+			headers.Set("Cache-Control", "public, max-age="+strconv.Itoa(maxAge))
+		} else if !cacheable {
+			headers.Set("Cache-Control", temporaryRedirectCaching)
+		}
+	}
+
+	// La destination d'un lien ciblé ou localisé dépend du visiteur : un cache ne doit pas la servir à un autre.
+	if len(link.TargetingRules) > 0 {
+		headers.Add("Vary", "User-Agent")
+	}
+	if len(link.Localized) > 0 {
+		headers.Add("Vary", "Accept-Language")
+	}
+
+	return RedirectResponse{Status: status, Headers: headers}
+}
+
+// privateRedirect indique si la redirection d'un lien dépend du visiteur : variante d'un test A/B,
+// ciblage par appareil ou par langue. Un cache, même celui du navigateur, figerait le visiteur sur une
+// destination et les visites suivantes n'atteindraient plus le serveur (statistiques par variante faussées).
+// La redirection d'un lien protégé n'est servie qu'aux visiteurs munis d'un cookie d'accès : un cache
+// partagé la servirait aux autres. Enfin, un lien à usage unique ou à budget de clics doit atteindre le
+// serveur à chaque visite, pour être consommé.
+func privateRedirect(link *models.Link) bool {
+	return len(link.Variants) > 0 || len(link.TargetingRules) > 0 || len(link.Localized) > 0 ||
+		IsPasswordProtected(link) || link.SingleUse || link.MaxClicks != nil
+}
+
+// isPermanentRedirect indique si un statut de redirection peut être mis en cache par les navigateurs.
+func isPermanentRedirect(status int) bool {
+	return status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/rules"
+)
+
+// Contraintes appliquées aux règles de redirection d'un lien.
+const (
+	maxRedirectRules = 50
+	maxCachedRuleSet = 10000 // Nombre de liens dont les règles compilées sont gardées en mémoire
+)
+
+// ErrInvalidRules est renvoyée lorsque les règles de redirection d'un lien sont invalides.
+var ErrInvalidRules = newDomainError(ErrInvalidInput, "invalid_rules", "redirect rules are invalid")
+
+// Origines possibles de la destination d'une visite, de la plus prioritaire à la moins prioritaire.
+const (
+	RouteRule      = "rule"      // Règle de redirection (expression)
+	RouteTargeting = "targeting" // Règle de ciblage par appareil
+	RouteTime      = "time"      // Règle horaire
+	RouteLanguage  = "language"  // Destination négociée avec Accept-Language
+	RouteVariant   = "variant"   // Variante d'un test A/B
+	RouteDefault   = "default"   // URL longue
+)
+
+// prepareRedirectRules compile les conditions des règles de redirection d'un lien pour les valider
+// et met leurs URLs sous forme canonique.
+func (s *LinkService) prepareRedirectRules(list []models.RedirectRule) ([]models.RedirectRule, error) {
+	if len(list) > maxRedirectRules {
+		return nil, fmt.Errorf("%w: a link can have at most %d rules", ErrInvalidRules, maxRedirectRules)
+	}
+
+	prepared := make([]models.RedirectRule, 0, len(list))
+	for i, rule := range list {
+		rule.Condition = strings.TrimSpace(rule.Condition)
+		if _, err := rules.Compile(rule.Condition); err != nil {
+			return nil, fmt.Errorf("%w: rule %d: %v", ErrInvalidRules, i+1, err)
+		}
+		destination, err := s.prepareDestination(rule.URL)
+		if err != nil {
+			return nil, fmt.Errorf("redirect rule %d: %w", i+1, err)
+		}
+		rule.URL = destination
+		prepared = append(prepared, rule)
+	}
+	return prepared, nil
+}
+
+// ruleCache garde les conditions compilées des liens, pour ne pas les recompiler à chaque visite.
+// Une entrée est identifiée par l'ID du lien et recompilée dès que ses conditions changent.
+type ruleCache struct {
+	mu      sync.Mutex
+	entries map[uint]compiledRules
+}
+
+// compiledRules sont les conditions compilées d'un lien. Une condition qui ne compile plus
+// (modifiée directement en base) est nil et ne correspond à aucune visite.
+type compiledRules struct {
+	fingerprint string
+	conditions  []*rules.Condition
+}
+
+func newRuleCache() *ruleCache {
+	return &ruleCache{entries: make(map[uint]compiledRules)}
+}
+
+// conditions renvoie les conditions compilées des règles d'un lien.
+func (c *ruleCache) conditions(link *models.Link) []*rules.Condition {
+	if len(link.RedirectRules) == 0 {
+		return nil
+	}
+	sources := make([]string, len(link.RedirectRules))
+	for i, rule := range link.RedirectRules {
+		sources[i] = rule.Condition
+	}
+	fingerprint := strings.Join(sources, "\x00")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[link.ID]; ok && entry.fingerprint == fingerprint {
+		return entry.conditions
+	}
+
+	conditions := make([]*rules.Condition, len(sources))
+	for i, source := range sources {
+		condition, err := rules.Compile(source)
+		if err != nil {
+			log.Printf("[RULES] Rule %d of link '%s' does not compile, it is skipped: %v", i+1, link.Shortcode, err)
+			continue
+		}
+		conditions[i] = condition
+	}
+	if len(c.entries) >= maxCachedRuleSet {
+		// Le cache est vidé plutôt que de suivre l'ancienneté des entrées : il se reconstitue au fil des visites.
+		c.entries = make(map[uint]compiledRules)
+	}
+	c.entries[link.ID] = compiledRules{fingerprint: fingerprint, conditions: conditions}
+	return conditions
+}
+
+// ruleRequest construit les attributs de visite sur lesquels portent les conditions.
+func ruleRequest(visit Visit, now time.Time) *rules.Request {
+	ua := ParseUserAgent(visit.UserAgent)
+	return &rules.Request{
+		Header:    visit.Header,
+		Query:     visit.Query,
+		Cookies:   visit.Cookies,
+		IP:        visit.ClientIP,
+		Path:      visit.Path,
+		UserAgent: rules.UserAgent{OS: ua.OS, Device: ua.Device, Browser: ua.Browser},
+		Time:      now,
+	}
+}
+
+// route est la destination choisie pour une visite, avant le transfert du chemin et de la query string.
+type route struct {
+	base     string
+	source   string // RouteRule, RouteTargeting...
+	rule     int    // Position (à partir de 1) de la règle appliquée, 0 sinon
+	variant  string
+	language string
+	// La règle appliquée a lu le chemin qui suit le code court pour choisir la destination.
+	pathConsumed bool
+}
+
+// destination construit l'URL de redirection de la visite. Un chemin lu par la règle appliquée a servi
+// à choisir la destination : il n'est pas transféré, et n'est pas refusé, sur un lien sans ForwardPath.
+func (r route) destination(link *models.Link, visit Visit) (string, error) {
+	rest := visit.Path
+	if r.pathConsumed && !link.ForwardPath {
+		rest = ""
+	}
+	return forwardTo(link, r.base, rest, visit.Query)
+}
+
+// routeVisit choisit la destination d'une visite. Les règles sont évaluées dans cet ordre :
+// règles de redirection, ciblage par appareil, plage horaire, langue, puis destination par défaut
+// (variantes comprises). La première qui correspond l'emporte.
+func (s *LinkService) routeVisit(link *models.Link, visit Visit, now time.Time) route {
+	if conditions := s.rules.conditions(link); len(conditions) > 0 {
+		req := ruleRequest(visit, now)
+		for i, condition := range conditions {
+			if condition != nil && condition.Match(req) {
+				return route{base: link.RedirectRules[i].URL, source: RouteRule, rule: i + 1, pathConsumed: condition.UsesPath()}
+			}
+		}
+	}
+	if i := matchTargeting(link.TargetingRules, ParseUserAgent(visit.UserAgent)); i >= 0 {
+		return route{base: link.TargetingRules[i].URL, source: RouteTargeting, rule: i + 1}
+	}
+	if i := matchTimeRule(compileTimeRules(link.TimeRules), now); i >= 0 {
+		return route{base: link.TimeRules[i].URL, source: RouteTime, rule: i + 1}
+	}
+	if tag, localized := negotiateLanguage(link.Localized, visit.AcceptLanguage); tag != "" {
+		return route{base: localized, source: RouteLanguage, language: tag}
+	}
+	if picked := pickVariant(link.Variants, visit.StickyVariant); picked != nil {
+		return route{base: picked.URL, source: RouteVariant, variant: picked.Name}
+	}
+	return route{base: link.LongURL, source: RouteDefault}
+}
+
+// RuleTrace décrit l'évaluation d'une règle de redirection lors d'un essai.
+type RuleTrace struct {
+	Index     int    `json:"index"`
+	Condition string `json:"condition"`
+	URL       string `json:"url"`
+	Matched   bool   `json:"matched"`
+	Error     string `json:"error,omitempty"` // Condition qui ne compile plus
+}
+
+// DryRun est le résultat de l'essai des règles d'un lien sur une visite fictive.
+type DryRun struct {
+	Destination string      `json:"destination"`
+	Source      string      `json:"source"`         // Origine de la destination : rule, targeting, time, language, variant ou default
+	Rule        int         `json:"rule,omitempty"` // Position de la règle appliquée, pour rule, targeting et time
+	Variant     string      `json:"variant,omitempty"`
+	Language    string      `json:"language,omitempty"`
+	State       string      `json:"state"` // État du lien à l'instant de la visite
+	Rules       []RuleTrace `json:"rules"` // Évaluation de chaque règle de redirection
+}
+
+// DryRunRules évalue les règles d'un lien sur une visite fictive, à l'instant donné, sans
+// enregistrer de clic. Toutes les règles de redirection sont évaluées pour la trace, même après
+// la première correspondance. L'expiration, la programmation et le mot de passe ne bloquent pas
+// l'essai : l'état du lien est indiqué à part.
+func (s *LinkService) DryRunRules(shortCode string, visit Visit, at time.Time) (*DryRun, error) {
+	link, err := s.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	req := ruleRequest(visit, at)
+	conditions := s.rules.conditions(link)
+	traces := make([]RuleTrace, len(link.RedirectRules))
+	for i, rule := range link.RedirectRules {
+		traces[i] = RuleTrace{Index: i + 1, Condition: rule.Condition, URL: rule.URL}
+		if conditions[i] == nil {
+			_, err := rules.Compile(rule.Condition)
+			traces[i].Error = err.Error()
+			continue
+		}
+		traces[i].Matched = conditions[i].Match(req)
+	}
+
+	r := s.routeVisit(link, visit, at)
+	destination, err := r.destination(link, visit)
+	if err != nil {
+		return nil, err
+	}
+	return &DryRun{
+		Destination: destination,
+		Source:      r.source,
+		Rule:        r.rule,
+		Variant:     r.variant,
+		Language:    r.language,
+		State:       LinkState(link, at),
+		Rules:       traces,
+	}, nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+func TestResolveLinkPathRules(t *testing.T) {
+	service := NewLinkService(repository.NewLinkRepository(newTestDB(t)))
+	pathRules := []models.RedirectRule{
+		{Condition: `path == "/docs"`, URL: "https://docs.example.com/"},
+		{Condition: `starts_with(path, "/blog")`, URL: "https://blog.example.com/"},
+	}
+	for alias, forwardPath := range map[string]bool{"paths": false, "forwarded": true} {
+		_, err := service.CreateLink(defaultURL, CreateLinkOptions{Alias: alias, ForwardPath: forwardPath, RedirectRules: pathRules})
+		if err != nil {
+			t.Fatalf("CreateLink %s: %v", alias, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		code    string
+		path    string
+		want    string
+		wantErr error
+	}{
+		{"rule reads the path", "paths", "/docs", "https://docs.example.com/", nil},
+		{"prefix rule reads the path", "paths", "/blog/2026/launch", "https://blog.example.com/", nil},
+		{"no path", "paths", "", defaultURL, nil},
+		{"path no rule consumed", "paths", "/other", "", ErrPathNotForwarded},
+		{"forwarded path after a rule", "forwarded", "/blog/2026", "https://blog.example.com/blog/2026", nil},
+		{"forwarded path without a rule", "forwarded", "/other", defaultURL + "/other", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolution, err := service.ResolveLink(tt.code, Visit{Path: tt.path})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ResolveLink error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveLink: %v", err)
+			}
+			if resolution.Destination != tt.want {
+				t.Errorf("destination = %q, want %q", resolution.Destination, tt.want)
+			}
+		})
+	}
+}
+
+func TestDryRunRules(t *testing.T) {
+	repo := repository.NewLinkRepository(newTestDB(t))
+	service := NewLinkService(repo)
+	expiresAt := utc("2026-11-01T00:00:00Z")
+	link, err := service.CreateLink(defaultURL, CreateLinkOptions{
+		Alias:     "rules",
+		ExpiresAt: &expiresAt,
+		RedirectRules: []models.RedirectRule{
+			{Condition: `header("X-Team") == "beta"`, URL: "https://example.com/beta"},
+			{Condition: `cookie("plan") == "pro" || ip_in(ip, "10.0.0.0/8")`, URL: "https://example.com/pro"},
+			{Condition: `weekday("Europe/Paris") == "sat"`, URL: "https://example.com/weekend"},
+		},
+		TimeRules: []models.TimeRule{{AvailabilityWindow: models.AvailabilityWindow{Start: "09:00", End: "18:00"}, URL: ruleURL}},
+	})
+	if err != nil {
+		t.Fatalf("CreateLink: %v", err)
+	}
+	// Une condition modifiée directement en base, qui ne compile plus, est tracée sans bloquer l'essai.
+	link.RedirectRules = append(link.RedirectRules, models.RedirectRule{Condition: `path ==`, URL: "https://example.com/broken"})
+	if err := repo.UpdateLink(link); err != nil {
+		t.Fatalf("UpdateLink: %v", err)
+	}
+
+	monday := utc("2026-10-12T07:30:00Z")
+	tests := []struct {
+		name        string
+		visit       Visit
+		at          time.Time
+		wantDest    string
+		wantSource  string
+		wantRule    int
+		wantMatched []bool
+		wantState   string
+	}{
+		{
+			name:        "first matching rule wins, all rules traced",
+			visit:       Visit{Header: http.Header{"X-Team": {"beta"}}, ClientIP: "10.0.0.1"},
+			at:          monday,
+			wantDest:    "https://example.com/beta",
+			wantSource:  RouteRule,
+			wantRule:    1,
+			wantMatched: []bool{true, true, false, false},
+			wantState:   LinkStateActive,
+		},
+		{
+			name:        "nil header, query and cookie maps",
+			visit:       Visit{ClientIP: "10.0.0.1"},
+			at:          monday,
+			wantDest:    "https://example.com/pro",
+			wantSource:  RouteRule,
+			wantRule:    2,
+			wantMatched: []bool{false, true, false, false},
+			wantState:   LinkStateActive,
+		},
+		{
+			name:        "no rule matches, time rule applies",
+			visit:       Visit{},
+			at:          monday.Add(2 * time.Hour),
+			wantDest:    ruleURL,
+			wantSource:  RouteTime,
+			wantRule:    1,
+			wantMatched: []bool{false, false, false, false},
+			wantState:   LinkStateActive,
+		},
+		{
+			name:        "clock rule at the given instant",
+			visit:       Visit{},
+			at:          utc("2026-10-17T06:00:00Z"),
+			wantDest:    "https://example.com/weekend",
+			wantSource:  RouteRule,
+			wantRule:    3,
+			wantMatched: []bool{false, false, true, false},
+			wantState:   LinkStateActive,
+		},
+		{
+			name:        "expired link is still evaluated",
+			visit:       Visit{},
+			at:          utc("2026-11-02T20:00:00Z"),
+			wantDest:    defaultURL,
+			wantSource:  RouteDefault,
+			wantMatched: []bool{false, false, false, false},
+			wantState:   LinkStateExpired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, err := service.DryRunRules("rules", tt.visit, tt.at)
+			if err != nil {
+				t.Fatalf("DryRunRules: %v", err)
+			}
+			if run.Destination != tt.wantDest || run.Source != tt.wantSource || run.Rule != tt.wantRule {
+				t.Errorf("dry run = %s from %s rule %d, want %s from %s rule %d",
+					run.Destination, run.Source, run.Rule, tt.wantDest, tt.wantSource, tt.wantRule)
+			}
+			if run.State != tt.wantState {
+				t.Errorf("State = %q, want %q", run.State, tt.wantState)
+			}
+			if len(run.Rules) != len(tt.wantMatched) {
+				t.Fatalf("%d rules traced, want %d", len(run.Rules), len(tt.wantMatched))
+			}
+			for i, trace := range run.Rules {
+				if trace.Index != i+1 || trace.Matched != tt.wantMatched[i] {
+					t.Errorf("rule %d: index %d, matched %v, want matched %v", i+1, trace.Index, trace.Matched, tt.wantMatched[i])
+				}
+			}
+			if broken := run.Rules[3]; broken.Error == "" {
+				t.Errorf("broken rule has no error in the trace")
+			}
+		})
+	}
+
+	// Un essai n'enregistre pas de clic.
+	stored, clicks, err := service.GetLinkStats("rules")
+	if err != nil {
+		t.Fatalf("GetLinkStats: %v", err)
+	}
+	if stored.UsedClicks != 0 || clicks != 0 {
+		t.Errorf("after dry runs: %d used clicks, %d clicks, want none", stored.UsedClicks, clicks)
+	}
+}
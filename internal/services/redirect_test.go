@@ -0,0 +1,88 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+func TestRedirectForCacheControl(t *testing.T) {
+	activateAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	maxClicks := 10
+
+	tests := []struct {
+		name string
+		link models.Link
+		want string
+	}{
+		{"permanent", models.Link{RedirectStatus: http.StatusMovedPermanently}, "public, max-age=86400"},
+		{"permanent 308", models.Link{RedirectStatus: http.StatusPermanentRedirect}, "public, max-age=86400"},
+		{"temporary", models.Link{RedirectStatus: http.StatusFound}, temporaryRedirectCaching},
+		{"explicit header", models.Link{
+			RedirectStatus:  http.StatusFound,
+			ResponseHeaders: map[string]string{"Cache-Control": "max-age=60"},
+		}, "max-age=60"},
+		{"activation date", models.Link{RedirectStatus: http.StatusMovedPermanently, ActivateAt: &activateAt}, temporaryRedirectCaching},
+		{"availability window", models.Link{
+			RedirectStatus: http.StatusMovedPermanently,
+			Availability:   &models.AvailabilityWindow{Start: "09:00", End: "18:00"},
+		}, temporaryRedirectCaching},
+		{"fallback URL", models.Link{RedirectStatus: http.StatusMovedPermanently, FallbackURL: "https://example.com/soon"}, temporaryRedirectCaching},
+		{"time rules", models.Link{
+			RedirectStatus: http.StatusMovedPermanently,
+			TimeRules:      []models.TimeRule{{AvailabilityWindow: models.AvailabilityWindow{Start: "09:00", End: "18:00"}, URL: ruleURL}},
+		}, temporaryRedirectCaching},
+		{"redirect rules", models.Link{
+			RedirectStatus: http.StatusMovedPermanently,
+			RedirectRules:  []models.RedirectRule{{Condition: `path == "/a"`, URL: ruleURL}},
+		}, temporaryRedirectCaching},
+		{"A/B variants", models.Link{
+			RedirectStatus: http.StatusMovedPermanently,
+			Variants:       []models.LinkVariant{{URL: ruleURL, Weight: 1}},
+		}, privateRedirectCaching},
+		{"single use", models.Link{RedirectStatus: http.StatusMovedPermanently, SingleUse: true}, privateRedirectCaching},
+		{"click budget", models.Link{RedirectStatus: http.StatusMovedPermanently, MaxClicks: &maxClicks}, privateRedirectCaching},
+		{"private overrides explicit header", models.Link{
+			RedirectStatus:  http.StatusMovedPermanently,
+			SingleUse:       true,
+			ResponseHeaders: map[string]string{"Cache-Control": "public, max-age=3600"},
+		}, privateRedirectCaching},
+	}
+
+	service := NewLinkService(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := service.RedirectFor(&tt.link)
+			if got := response.Headers.Get("Cache-Control"); got != tt.want {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedirectForExpiringLink(t *testing.T) {
+	now := utc("2026-10-12T12:00:00Z")
+	service := NewLinkService(nil, WithClock(func() time.Time { return now }))
+
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+		want      string
+	}{
+		{"expires after the cache duration", 48 * time.Hour, "public, max-age=86400"},
+		{"expires before the cache duration", 10 * time.Minute, "public, max-age=600"},
+		{"expires within the second", 500 * time.Millisecond, temporaryRedirectCaching},
+		{"already expired", -time.Minute, temporaryRedirectCaching},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expiresAt := now.Add(tt.expiresIn)
+			response := service.RedirectFor(&models.Link{RedirectStatus: http.StatusMovedPermanently, ExpiresAt: &expiresAt})
+			if got := response.Headers.Get("Cache-Control"); got != tt.want {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
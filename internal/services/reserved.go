@@ -0,0 +1,162 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// builtinReservedWords liste les noms des routes exposées à la racine par le serveur (ou susceptibles
+// de l'être). Un code court identique masquerait la route ou serait masqué par elle : ils sont
+// toujours réservés, quelle que soit la configuration.
+var builtinReservedWords = []string{"api", "health", "admin", "static", "assets", "favicon", "robots"}
+
+// DefaultProfanityList est la liste de mots grossiers filtrés par défaut dans les codes courts,
+// y compris écrits en "leet speak" (ex: "sh1t") ou accolés à d'autres mots (ex: "shitpost").
+var DefaultProfanityList = []string{
+	"fuck", "shit", "cunt", "bitch", "dick", "cock", "piss", "slut", "whore", "nazi", "ass",
+	"merde", "putain", "connard", "salope", "encule",
+}
+
+// DefaultProfanityAllowlist liste des mots courants qui contiennent un mot grossier sans en être un
+// (ex: "class" contient "ass") : ils ne suffisent pas à écarter un code.
+var DefaultProfanityAllowlist = []string{
+	"class", "assess", "assist", "assign", "asset", "assume", "assort", "bass", "brass", "compass",
+	"embassy", "glass", "grass", "mass", "pass", "cassette", "passion", "sassy",
+	"cocktail", "cockpit", "cockroach", "peacock", "hancock", "hitchcock", "dickens", "scunthorpe",
+}
+
+// leetReplacer ramène les substitutions de caractères courantes à la lettre d'origine
+// avant la recherche de mots grossiers.
+var leetReplacer = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s")
+
+// ReservedWords est le registre des codes courts qui ne peuvent être ni générés ni réservés comme alias :
+// les noms de routes intégrés, une liste configurable de mots réservés et, si le filtre est actif,
+// les codes contenant un mot grossier.
+type ReservedWords struct {
+	words     map[string]struct{} // Mots réservés en minuscules, comparés au code entier
+	profanity []string            // Mots grossiers en minuscules, recherchés partout dans le code
+	allowed   *strings.Replacer   // Masque les mots de la liste d'exceptions avant la recherche, nil si elle est vide
+}
+
+// NewReservedWords crée un registre contenant les noms de routes intégrés, les mots donnés,
+// les mots grossiers donnés (une liste vide désactive le filtre) et les exceptions au filtre.
+// La casse est ignorée.
+func NewReservedWords(words, profanity, allowlist []string) *ReservedWords {
+	r := &ReservedWords{words: make(map[string]struct{})}
+	for _, w := range append(append([]string{}, builtinReservedWords...), words...) {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			r.words[w] = struct{}{}
+		}
+	}
+	for _, w := range profanity {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			r.profanity = append(r.profanity, w)
+		}
+	}
+
+	// Les exceptions les plus longues d'abord : "passion" est masqué en entier plutôt que "pass".
+	var allowed []string
+	for _, w := range allowlist {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			allowed = append(allowed, w)
+		}
+	}
+	sort.Slice(allowed, func(i, j int) bool { return len(allowed[i]) > len(allowed[j]) })
+	var pairs []string
+	for _, w := range allowed {
+		pairs = append(pairs, w, strings.Repeat("*", len(w)))
+	}
+	if len(pairs) > 0 {
+		r.allowed = strings.NewReplacer(pairs...)
+	}
+	return r
+}
+
+// defaultReservedWords est le registre utilisé lorsqu'aucun n'est configuré.
+var defaultReservedWords = NewReservedWords(nil, DefaultProfanityList, DefaultProfanityAllowlist)
+
+// Reason indique si un code est réservé et, le cas échéant, pour quelle raison.
+// Les mots grossiers sont recherchés partout dans le code ("fuckoff", "my-Shit"), tel quel et ramené
+// du "leet speak" ("sh1t"). Les mots de la liste d'exceptions sont masqués avant la recherche :
+// "classic" ou "cocktail" restent acceptés.
+func (r *ReservedWords) Reason(code string) (string, bool) {
+	lower := strings.ToLower(code)
+	if _, ok := r.words[lower]; ok {
+		return "reserved word", true
+	}
+
+	for _, text := range []string{lower, leetReplacer.Replace(lower)} {
+		if r.allowed != nil {
+			text = r.allowed.Replace(text)
+		}
+		for _, w := range r.profanity {
+			if strings.Contains(text, w) {
+				return "contains a blocked word", true
+			}
+		}
+	}
+	return "", false
+}
+
+// IsReserved indique si un code est réservé.
+func (r *ReservedWords) IsReserved(code string) bool {
+	_, reserved := r.Reason(code)
+	return reserved
+}
+
+// WithReservedWords remplace le registre des codes réservés du service.
+func WithReservedWords(reserved *ReservedWords) LinkServiceOption {
+	return func(s *LinkService) {
+		s.reserved = reserved
+	}
+}
+
+// checkReserved renvoie ErrReservedAlias si un alias est réservé.
+func (s *LinkService) checkReserved(alias string) error {
+	if reason, reserved := s.reserved.Reason(alias); reserved {
+		return fmt.Errorf("%w: '%s' (%s)", ErrReservedAlias, alias, reason)
+	}
+	return nil
+}
+
+// ReservedCollision décrit un lien existant dont le code est désormais réservé.
+type ReservedCollision struct {
+	ShortCode string
+	Reason    string
+}
+
+// FindReservedCollisions liste les liens existants dont le code entre en conflit avec le registre,
+// par exemple après l'ajout d'un mot réservé ou d'une nouvelle route.
+func (s *LinkService) FindReservedCollisions() ([]ReservedCollision, error) {
+	codes, err := s.linkRepo.GetAllShortCodes()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving short codes: %w", err)
+	}
+
+	var collisions []ReservedCollision
+	for _, code := range codes {
+		if reason, reserved := s.reserved.Reason(code); reserved {
+			collisions = append(collisions, ReservedCollision{ShortCode: code, Reason: reason})
+		}
+	}
+	sort.Slice(collisions, func(i, j int) bool { return collisions[i].ShortCode < collisions[j].ShortCode })
+	return collisions, nil
+}
+
+// WarnReservedCollisions journalise un avertissement pour chaque lien existant dont le code est réservé.
+// Ces liens restent en base, mais leur code peut être masqué par une route du serveur.
+func (s *LinkService) WarnReservedCollisions() {
+	collisions, err := s.FindReservedCollisions()
+	if err != nil {
+		log.Printf("[RESERVED] Impossible de vérifier les codes réservés : %v", err)
+		return
+	}
+	for _, c := range collisions {
+		log.Printf("[RESERVED] WARNING: le lien existant '%s' utilise un code réservé (%s).", c.ShortCode, c.Reason)
+	}
+	if len(collisions) > 0 {
+		log.Printf("[RESERVED] %d lien(s) existant(s) en conflit avec les mots réservés.", len(collisions))
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import "testing"
+
+func TestReservedWordsReason(t *testing.T) {
+	reserved := NewReservedWords([]string{"login"}, DefaultProfanityList, DefaultProfanityAllowlist)
+
+	tests := []struct {
+		code       string
+		wantReason string // Vide si le code est accepté
+	}{
+		// Mots réservés : le code entier, casse ignorée.
+		{"api", "reserved word"},
+		{"Health", "reserved word"},
+		{"login", "reserved word"},
+		{"apis", ""},
+
+		// Mots grossiers, seuls, accolés ou séparés.
+		{"fuckoff", "contains a blocked word"},
+		{"shitpost", "contains a blocked word"},
+		{"assholes2", "contains a blocked word"},
+		{"my-shit", "contains a blocked word"},
+		{"myShit", "contains a blocked word"},
+		{"a_cunt_b", "contains a blocked word"},
+		{"Fuck", "contains a blocked word"},
+		{"shit2024", "contains a blocked word"},
+		{"putain", "contains a blocked word"},
+		// Leet speak.
+		{"sh1t", "contains a blocked word"},
+		{"f4ssfuck", "contains a blocked word"},
+		{"b1tch", "contains a blocked word"},
+		{"@ss", "contains a blocked word"},
+		// Une exception ne couvre que ses propres lettres.
+		{"classyshit", "contains a blocked word"},
+		{"passass", "contains a blocked word"},
+
+		// Exceptions.
+		{"class", ""},
+		{"classic", ""},
+		{"assess", ""},
+		{"assets2025", ""},
+		{"password", ""},
+		{"cl4ss", ""},
+		{"cocktail", ""},
+		{"Peacock", ""},
+		{"dickens", ""},
+		{"scunthorpe", ""},
+		{"launch", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			reason, ok := reserved.Reason(tt.code)
+			if reason != tt.wantReason || ok != (tt.wantReason != "") {
+				t.Errorf("Reason(%q) = %q, %v, want %q", tt.code, reason, ok, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestReservedWordsFilterDisabled(t *testing.T) {
+	reserved := NewReservedWords(nil, nil, DefaultProfanityAllowlist)
+	for _, code := range []string{"shitpost", "fuckoff", "class"} {
+		if reserved.IsReserved(code) {
+			t.Errorf("IsReserved(%q) = true with the filter disabled", code)
+		}
+	}
+	if !reserved.IsReserved("admin") {
+		t.Error("IsReserved(\"admin\") = false, built-in route names are always reserved")
+	}
+}
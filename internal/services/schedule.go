@@ -0,0 +1,215 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // Base des fuseaux horaires embarquée, pour ne pas dépendre de celle du système
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// États du cycle de vie d'un lien, exposés par les APIs de listing et de statistiques.
+const (
+	LinkStateScheduled = "scheduled" // Pas encore activé, ou hors de sa plage de disponibilité
+	LinkStateActive    = "active"
+	LinkStateExpired   = "expired"
+	LinkStateDisabled  = "disabled"
+)
+
+// Erreurs liées à la programmation des liens. Un lien désactivé existe mais ne sert plus :
+// comme un lien expiré, il répond 410 Gone et non 404.
+var (
+	ErrLinkDisabled        = newDomainError(ErrExpired, "link_disabled", "link is disabled")
+	ErrLinkNotAvailable    = newDomainError(ErrUnavailable, "link_not_available", "link is not available yet")
+	ErrInvalidSchedule     = newDomainError(ErrInvalidInput, "invalid_schedule", "schedule settings are invalid")
+	errNoUpcomingAvailable = errors.New("no upcoming availability")
+)
+
+// weekdays associe les noms de jours acceptés dans une plage de disponibilité aux jours de la semaine.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// availability est une plage de disponibilité validée et prête à être évaluée.
+type availability struct {
+	days       [7]bool
+	start, end int // Minutes depuis minuit
+	loc        *time.Location
+}
+
+// parseAvailability valide une plage de disponibilité. Une plage nil donne nil.
+func parseAvailability(w *models.AvailabilityWindow) (*availability, error) {
+	if w == nil {
+		return nil, nil
+	}
+
+	a := &availability{loc: time.UTC}
+	if w.Timezone != "" {
+		loc, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown timezone '%s'", ErrInvalidSchedule, w.Timezone)
+		}
+		a.loc = loc
+	}
+
+	if len(w.Days) == 0 {
+		a.days = [7]bool{true, true, true, true, true, true, true}
+	}
+	for _, name := range w.Days {
+		day, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown day '%s', use mon, tue, wed, thu, fri, sat or sun", ErrInvalidSchedule, name)
+		}
+		a.days[day] = true
+	}
+
+	var err error
+	if a.start, err = parseClock(w.Start); err != nil {
+		return nil, err
+	}
+	if a.end, err = parseClock(w.End); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// parseClock convertit une heure "HH:MM" en minutes depuis minuit.
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid time '%s', expected HH:MM", ErrInvalidSchedule, value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// open indique si la plage est ouverte à l'instant donné. Une plage dont la fin précède le début
+// passe minuit : elle appartient au jour où elle commence. Une plage dont le début égale la fin
+// couvre la journée entière.
+func (a *availability) open(t time.Time) bool {
+	local := t.In(a.loc)
+	minute := local.Hour()*60 + local.Minute()
+	day := local.Weekday()
+	previous := (day + 6) % 7
+
+	switch {
+	case a.start == a.end:
+		return a.days[day]
+	case a.start < a.end:
+		return a.days[day] && minute >= a.start && minute < a.end
+	default:
+		return (a.days[day] && minute >= a.start) || (a.days[previous] && minute < a.end)
+	}
+}
+
+// nextOpening renvoie le prochain début de plage strictement postérieur à t.
+func (a *availability) nextOpening(t time.Time) (time.Time, error) {
+	local := t.In(a.loc)
+	for offset := 0; offset <= 7; offset++ {
+		date := local.AddDate(0, 0, offset)
+		if !a.days[date.Weekday()] {
+			continue
+		}
+		start := time.Date(date.Year(), date.Month(), date.Day(), a.start/60, a.start%60, 0, 0, a.loc)
+		if start.After(t) {
+			return start, nil
+		}
+	}
+	return time.Time{}, errNoUpcomingAvailable
+}
+
+// validateSchedule vérifie la date d'activation et la plage de disponibilité d'un lien.
+func validateSchedule(activateAt, expiresAt *time.Time, window *models.AvailabilityWindow) error {
+	if activateAt != nil && expiresAt != nil && !activateAt.Before(*expiresAt) {
+		return fmt.Errorf("%w: activate_at must be before expires_at", ErrInvalidSchedule)
+	}
+	_, err := parseAvailability(window)
+	return err
+}
+
+// availableAt indique si un lien peut être servi à l'instant donné. Sinon, elle renvoie la date
+// à laquelle il le sera, ou nil si elle ne peut pas être déterminée.
+func availableAt(link *models.Link, now time.Time) (bool, *time.Time) {
+	window, err := parseAvailability(link.Availability)
+	if err != nil {
+		// Une plage invalide ne peut provenir que d'une modification directe en base.
+		return false, nil
+	}
+
+	from := now
+	if link.ActivateAt != nil && now.Before(*link.ActivateAt) {
+		from = *link.ActivateAt
+	}
+	if window == nil || window.open(from) {
+		if from.Equal(now) {
+			return true, nil
+		}
+		return false, &from
+	}
+
+	next, err := window.nextOpening(from)
+	if err != nil {
+		return false, nil
+	}
+	return false, &next
+}
+
+// LinkState renvoie l'état du cycle de vie d'un lien à l'instant donné.
+func LinkState(link *models.Link, now time.Time) string {
+	switch {
+	case link.Disabled:
+		return LinkStateDisabled
+	case IsLinkExpired(link, now):
+		return LinkStateExpired
+	}
+	if available, _ := availableAt(link, now); !available {
+		return LinkStateScheduled
+	}
+	return LinkStateActive
+}
+
+// LinkUnavailable décrit pourquoi et jusqu'à quand un lien programmé ne peut pas être servi.
+type LinkUnavailable struct {
+	AvailableAt *time.Time // Prochaine disponibilité, nil si inconnue
+	FallbackURL string     // URL de repli du lien, vide s'il n'en a pas
+}
+
+// notAvailableError est l'erreur renvoyée lorsqu'un lien est visité avant son activation ou hors de sa plage.
+type notAvailableError struct {
+	shortCode string
+	info      LinkUnavailable
+}
+
+func (e *notAvailableError) Error() string {
+	if e.info.AvailableAt != nil {
+		return fmt.Sprintf("%s: '%s' until %s", ErrLinkNotAvailable.Error(), e.shortCode, e.info.AvailableAt.UTC().Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s: '%s'", ErrLinkNotAvailable.Error(), e.shortCode)
+}
+
+func (e *notAvailableError) Unwrap() error { return ErrLinkNotAvailable }
+
+// Unavailability renvoie les détails portés par une erreur ErrLinkNotAvailable.
+func Unavailability(err error) (LinkUnavailable, bool) {
+	var notAvailable *notAvailableError
+	if errors.As(err, &notAvailable) {
+		return notAvailable.info, true
+	}
+	return LinkUnavailable{}, false
+}
+
+// checkAvailability renvoie une erreur si le lien est désactivé ou pas disponible à l'instant donné.
+func checkAvailability(link *models.Link, now time.Time) error {
+	if link.Disabled {
+		return fmt.Errorf("%w: '%s'", ErrLinkDisabled, link.Shortcode)
+	}
+	if available, next := availableAt(link, now); !available {
+		return &notAvailableError{
+			shortCode: link.Shortcode,
+			info:      LinkUnavailable{AvailableAt: next, FallbackURL: link.FallbackURL},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResolveLinkLifecycleErrors(t *testing.T) {
+	now := utc("2026-10-12T12:00:00Z")
+	service := newClockedService(t, &now)
+
+	expiresAt, activateAt := now.Add(time.Hour), now.Add(24*time.Hour)
+	for alias, opts := range map[string]CreateLinkOptions{
+		"disabled":  {},
+		"expired":   {ExpiresAt: &expiresAt},
+		"scheduled": {ActivateAt: &activateAt},
+	} {
+		opts.Alias = alias
+		if _, err := service.CreateLink(defaultURL, opts); err != nil {
+			t.Fatalf("CreateLink %s: %v", alias, err)
+		}
+	}
+	disabled := true
+	if _, err := service.UpdateLink("disabled", LinkUpdate{Disabled: &disabled}); err != nil {
+		t.Fatalf("UpdateLink: %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+
+	// La catégorie décide du statut HTTP : ErrExpired donne 410 Gone, ErrNotFound 404.
+	tests := []struct {
+		code     string
+		wantKind error
+		wantCode string
+	}{
+		{"disabled", ErrExpired, "link_disabled"},
+		{"expired", ErrExpired, "link_expired"},
+		{"scheduled", ErrUnavailable, "link_not_available"},
+		{"missing", ErrNotFound, "link_not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			_, err := service.ResolveLink(tt.code, Visit{})
+			if !errors.Is(err, tt.wantKind) {
+				t.Errorf("ResolveLink error = %v, want a %v error", err, tt.wantKind)
+			}
+			if code := ErrorCode(err); code != tt.wantCode {
+				t.Errorf("ErrorCode = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+}
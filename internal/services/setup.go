@@ -0,0 +1,60 @@
+package services
+
+import (
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// NewLinkServiceFromConfig construit un LinkService configuré selon la configuration de l'application.
+// Le serveur et les commandes CLI l'utilisent pour partager exactement le même comportement.
+// Les options supplémentaires sont appliquées après celles issues de la configuration.
+func NewLinkServiceFromConfig(linkRepo repository.LinkRepository, cfg *config.Config, extra ...LinkServiceOption) (*LinkService, error) {
+	generator, err := NewCodeGenerator(cfg.ShortCode.Strategy, cfg.ShortCode.Alphabet)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []LinkServiceOption{
+		WithCodeGenerator(generator, cfg.ShortCode.Length),
+		WithURLCanonicalizer(NewURLCanonicalizer(cfg.Canonicalization.TrackingParams)),
+	}
+	redirect := RedirectDefaults{
+		Status:          cfg.Redirect.StatusCode,
+		Headers:         cfg.Redirect.Headers,
+		PermanentMaxAge: cfg.Redirect.PermanentMaxAgeSeconds,
+	}
+	if err := ValidateRedirectDefaults(redirect); err != nil {
+		return nil, err
+	}
+	opts = append(opts, WithRedirectDefaults(redirect))
+
+	opts = append(opts, WithPasswordProtection(PasswordProtection{
+		CookieSecret:  cfg.PasswordProtection.CookieSecret,
+		AccessTTL:     time.Duration(cfg.PasswordProtection.CookieTTLMinutes) * time.Minute,
+		MaxAttempts:   cfg.PasswordProtection.MaxAttempts,
+		AttemptWindow: time.Duration(cfg.PasswordProtection.AttemptWindowMinutes) * time.Minute,
+	}))
+
+	var profanity, allowlist []string
+	if cfg.ReservedWords.ProfanityFilter {
+		profanity = append(append(profanity, DefaultProfanityList...), cfg.ReservedWords.Profanity...)
+		allowlist = append(append(allowlist, DefaultProfanityAllowlist...), cfg.ReservedWords.ProfanityAllowlist...)
+	}
+	opts = append(opts, WithReservedWords(NewReservedWords(cfg.ReservedWords.Words, profanity, allowlist)))
+
+	if adaptive := cfg.ShortCode.Adaptive; adaptive.Enabled {
+		opts = append(opts, WithAdaptiveLength(adaptive.CollisionThreshold, adaptive.WindowSize, adaptive.MaxLength))
+	}
+
+	opts = append(opts, extra...)
+
+	return NewLinkService(linkRepo, opts...), nil
+}
+
+// LoadDestinationPolicyFromConfig charge la politique de domaines configurée.
+// Les liens vers server.base_url et vers les adresses locales sur server.port sont toujours refusés.
+func LoadDestinationPolicyFromConfig(cfg *config.Config) (*DestinationPolicy, error) {
+	return LoadDestinationPolicy(cfg.DestinationPolicy.File, cfg.Server.Port, cfg.Server.BaseURL)
+}
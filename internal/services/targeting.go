@@ -0,0 +1,83 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// maxTargetingRules limite le nombre de règles de ciblage d'un lien.
+const maxTargetingRules = 20
+
+// ErrInvalidTargeting est renvoyée lorsque les règles de ciblage d'un lien sont invalides.
+var ErrInvalidTargeting = newDomainError(ErrInvalidInput, "invalid_targeting", "targeting rules are invalid")
+
+// Valeurs acceptées pour chaque critère d'une règle de ciblage.
+var (
+	targetingOSValues      = []string{OSiOS, OSAndroid, OSWindows, OSMacOS, OSLinux, OSChromeOS, OSOther}
+	targetingDeviceValues  = []string{DeviceMobile, DeviceTablet, DeviceDesktop, DeviceBot}
+	targetingBrowserValues = []string{BrowserChrome, BrowserSafari, BrowserFirefox, BrowserEdge, BrowserOpera, BrowserSamsung, BrowserOther}
+)
+
+// prepareTargeting valide les règles de ciblage d'un lien, normalise leurs critères
+// et met leurs URLs sous forme canonique.
+func (s *LinkService) prepareTargeting(rules []models.TargetingRule) ([]models.TargetingRule, error) {
+	if len(rules) > maxTargetingRules {
+		return nil, fmt.Errorf("%w: a link can have at most %d rules", ErrInvalidTargeting, maxTargetingRules)
+	}
+
+	prepared := make([]models.TargetingRule, 0, len(rules))
+	for i, rule := range rules {
+		rule.OS = strings.ToLower(strings.TrimSpace(rule.OS))
+		rule.Device = strings.ToLower(strings.TrimSpace(rule.Device))
+		rule.Browser = strings.ToLower(strings.TrimSpace(rule.Browser))
+
+		if rule.OS == "" && rule.Device == "" && rule.Browser == "" {
+			return nil, fmt.Errorf("%w: rule %d needs at least one of os, device or browser", ErrInvalidTargeting, i+1)
+		}
+		if err := checkTargetingValue(i, "os", rule.OS, targetingOSValues); err != nil {
+			return nil, err
+		}
+		if err := checkTargetingValue(i, "device", rule.Device, targetingDeviceValues); err != nil {
+			return nil, err
+		}
+		if err := checkTargetingValue(i, "browser", rule.Browser, targetingBrowserValues); err != nil {
+			return nil, err
+		}
+
+		destination, err := s.prepareDestination(rule.URL)
+		if err != nil {
+			return nil, fmt.Errorf("targeting rule %d: %w", i+1, err)
+		}
+		rule.URL = destination
+		prepared = append(prepared, rule)
+	}
+	return prepared, nil
+}
+
+// checkTargetingValue vérifie qu'un critère est vide ou fait partie des valeurs acceptées.
+func checkTargetingValue(index int, field, value string, allowed []string) error {
+	if value == "" {
+		return nil
+	}
+	for _, v := range allowed {
+		if value == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: rule %d has unknown %s '%s', use %s", ErrInvalidTargeting, index+1, field, value, strings.Join(allowed, ", "))
+}
+
+// matchTargeting renvoie l'index de la première règle dont tous les critères correspondent
+// à l'appareil du visiteur, ou -1 si aucune ne correspond.
+func matchTargeting(rules []models.TargetingRule, ua UserAgent) int {
+	for i, rule := range rules {
+		if (rule.OS == "" || rule.OS == ua.OS) &&
+			(rule.Device == "" || rule.Device == ua.Device) &&
+			(rule.Browser == "" || rule.Browser == ua.Browser) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// maxTimeRules limite le nombre de règles horaires d'un lien.
+const maxTimeRules = 20
+
+// ErrInvalidTimeRules est renvoyée lorsque les règles horaires d'un lien sont invalides.
+var ErrInvalidTimeRules = newDomainError(ErrInvalidInput, "invalid_time_rules", "time rules are invalid")
+
+// WithClock remplace l'horloge utilisée pour évaluer l'expiration, la programmation et les règles
+// horaires des liens. Elle permet de rejouer une visite à un instant donné.
+func WithClock(clock func() time.Time) LinkServiceOption {
+	return func(s *LinkService) {
+		s.clock = clock
+	}
+}
+
+// prepareTimeRules valide les règles horaires d'un lien et met leurs URLs sous forme canonique.
+// Les plages suivent les mêmes règles que la plage de disponibilité d'un lien.
+func (s *LinkService) prepareTimeRules(rules []models.TimeRule) ([]models.TimeRule, error) {
+	if len(rules) > maxTimeRules {
+		return nil, fmt.Errorf("%w: a link can have at most %d rules", ErrInvalidTimeRules, maxTimeRules)
+	}
+
+	prepared := make([]models.TimeRule, 0, len(rules))
+	for i, rule := range rules {
+		if _, err := parseAvailability(&rule.AvailabilityWindow); err != nil {
+			return nil, fmt.Errorf("time rule %d: %w", i+1, err)
+		}
+		destination, err := s.prepareDestination(rule.URL)
+		if err != nil {
+			return nil, fmt.Errorf("time rule %d: %w", i+1, err)
+		}
+		rule.URL = destination
+		prepared = append(prepared, rule)
+	}
+	return prepared, nil
+}
+
+// compileTimeRules prépare l'évaluation des plages des règles horaires d'un lien.
+// Une règle invalide, qui ne peut provenir que d'une modification directe en base, donne nil.
+func compileTimeRules(rules []models.TimeRule) []*availability {
+	windows := make([]*availability, len(rules))
+	for i := range rules {
+		windows[i], _ = parseAvailability(&rules[i].AvailabilityWindow)
+	}
+	return windows
+}
+
+// matchTimeRule renvoie l'index de la première règle dont la plage est ouverte à l'instant donné,
+// ou -1 si aucune ne l'est.
+func matchTimeRule(windows []*availability, now time.Time) int {
+	for i, window := range windows {
+		if window != nil && window.open(now) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ActiveRoute décrit la destination horaire d'un lien à un instant donné.
+type ActiveRoute struct {
+	Destination string
+	Rule        *models.TimeRule // Règle horaire appliquée, nil si c'est l'URL longue
+	RuleIndex   int              // Position de la règle (à partir de 1), 0 si c'est l'URL longue
+	// Instant du prochain changement de destination, nil s'il ne peut pas être déterminé dans la semaine.
+	NextChange *time.Time
+}
+
+// ActiveDestination indique vers quelle destination les règles horaires d'un lien dirigent
+// à l'instant donné. Les règles qui dépendent du visiteur (ciblage, langue, variantes) ne sont
+// pas évaluées.
+func (s *LinkService) ActiveDestination(shortCode string, at time.Time) (*models.Link, *ActiveRoute, error) {
+	link, err := s.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	route := &ActiveRoute{Destination: link.LongURL}
+	windows := compileTimeRules(link.TimeRules)
+	current := matchTimeRule(windows, at)
+	if current >= 0 {
+		route.Rule = &link.TimeRules[current]
+		route.RuleIndex = current + 1
+		route.Destination = route.Rule.URL
+	}
+
+	// Les plages sont à la minute : le prochain changement est cherché minute par minute sur une semaine.
+	if len(link.TimeRules) > 0 {
+		start := at.Truncate(time.Minute)
+		for t := start.Add(time.Minute); t.Sub(start) <= 7*24*time.Hour; t = t.Add(time.Minute) {
+			if matchTimeRule(windows, t) != current {
+				route.NextChange = &t
+				break
+			}
+		}
+	}
+	return link, route, nil
+}
+
+// now renvoie l'instant courant selon l'horloge du service.
+func (s *LinkService) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock()
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+const (
+	ruleURL    = "https://example.com/rule"
+	defaultURL = "https://example.com/default"
+)
+
+// newClockedService renvoie un service dont l'horloge lit l'instant pointé par now.
+func newClockedService(t *testing.T, now *time.Time) *LinkService {
+	t.Helper()
+	return NewLinkService(repository.NewLinkRepository(newTestDB(t)),
+		WithClock(func() time.Time { return *now }))
+}
+
+// utc analyse un instant RFC 3339 des tables de test.
+func utc(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestResolveLinkTimeRules(t *testing.T) {
+	businessHours := models.AvailabilityWindow{
+		Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "09:00", End: "18:00", Timezone: "Europe/Paris",
+	}
+	// Plage qui passe minuit : elle appartient au vendredi et se termine le samedi à 02:00.
+	fridayNight := models.AvailabilityWindow{
+		Days: []string{"fri"}, Start: "22:00", End: "02:00", Timezone: "America/New_York",
+	}
+	earlyMonday := models.AvailabilityWindow{
+		Days: []string{"mon"}, Start: "00:00", End: "02:00", Timezone: "Europe/Paris",
+	}
+	wholeSunday := models.AvailabilityWindow{Days: []string{"sun"}, Start: "00:00", End: "00:00"}
+
+	tests := []struct {
+		name   string
+		window models.AvailabilityWindow
+		at     string // Instant de la visite, en UTC
+		want   string
+	}{
+		// 2026-10-12 est un lundi ; Paris est en UTC+2 jusqu'au 25 octobre.
+		{"start is included", businessHours, "2026-10-12T07:00:00Z", ruleURL},
+		{"before start", businessHours, "2026-10-12T06:59:00Z", defaultURL},
+		{"last minute", businessHours, "2026-10-12T15:59:59Z", ruleURL},
+		{"end is excluded", businessHours, "2026-10-12T16:00:00Z", defaultURL},
+		{"day not listed", businessHours, "2026-10-17T10:00:00Z", defaultURL},
+		// Passage à l'heure d'hiver le 25 octobre : Paris est en UTC+1.
+		{"winter time start", businessHours, "2026-10-26T08:00:00Z", ruleURL},
+		{"winter time before start", businessHours, "2026-10-26T07:30:00Z", defaultURL},
+		// Dimanche 22:30 UTC est déjà lundi 00:30 à Paris.
+		{"weekday of the timezone", earlyMonday, "2026-10-11T22:30:00Z", ruleURL},
+		{"weekday of the timezone, UTC monday", earlyMonday, "2026-10-12T00:30:00Z", defaultURL},
+		// New York est en UTC-4 : vendredi 22:00 = samedi 02:00 UTC.
+		{"overnight start", fridayNight, "2026-10-17T02:00:00Z", ruleURL},
+		{"overnight before start", fridayNight, "2026-10-17T01:59:00Z", defaultURL},
+		{"overnight after midnight", fridayNight, "2026-10-17T05:59:00Z", ruleURL},
+		{"overnight end is excluded", fridayNight, "2026-10-17T06:00:00Z", defaultURL},
+		{"overnight, next day not listed", fridayNight, "2026-10-18T03:00:00Z", defaultURL},
+		{"overnight, previous day not listed", fridayNight, "2026-10-16T05:00:00Z", defaultURL},
+		{"whole day start", wholeSunday, "2026-10-18T00:00:00Z", ruleURL},
+		{"whole day end", wholeSunday, "2026-10-18T23:59:59Z", ruleURL},
+		{"whole day, next day", wholeSunday, "2026-10-19T00:00:00Z", defaultURL},
+	}
+
+	var now time.Time
+	service := newClockedService(t, &now)
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alias := fmt.Sprintf("rule-%d", i)
+			_, err := service.CreateLink(defaultURL, CreateLinkOptions{
+				Alias:     alias,
+				TimeRules: []models.TimeRule{{AvailabilityWindow: tt.window, URL: ruleURL}},
+			})
+			if err != nil {
+				t.Fatalf("CreateLink: %v", err)
+			}
+
+			now = utc(tt.at)
+			resolution, err := service.ResolveLink(alias, Visit{})
+			if err != nil {
+				t.Fatalf("ResolveLink: %v", err)
+			}
+			if resolution.Destination != tt.want {
+				t.Errorf("destination at %s = %q, want %q", tt.at, resolution.Destination, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveLinkTimeRulesFirstMatchWins(t *testing.T) {
+	var now time.Time
+	service := newClockedService(t, &now)
+	_, err := service.CreateLink(defaultURL, CreateLinkOptions{
+		Alias: "lunch",
+		TimeRules: []models.TimeRule{
+			{AvailabilityWindow: models.AvailabilityWindow{Start: "12:00", End: "14:00"}, URL: "https://example.com/lunch"},
+			{AvailabilityWindow: models.AvailabilityWindow{Start: "08:00", End: "20:00"}, URL: "https://example.com/day"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateLink: %v", err)
+	}
+
+	for at, want := range map[string]string{
+		"2026-10-12T11:59:00Z": "https://example.com/day",
+		"2026-10-12T12:00:00Z": "https://example.com/lunch",
+		"2026-10-12T14:00:00Z": "https://example.com/day",
+		"2026-10-12T20:00:00Z": defaultURL,
+	} {
+		now = utc(at)
+		resolution, err := service.ResolveLink("lunch", Visit{})
+		if err != nil {
+			t.Fatalf("ResolveLink at %s: %v", at, err)
+		}
+		if resolution.Destination != want {
+			t.Errorf("destination at %s = %q, want %q", at, resolution.Destination, want)
+		}
+	}
+}
+
+func TestActiveDestinationNextChange(t *testing.T) {
+	var now time.Time
+	service := newClockedService(t, &now)
+	_, err := service.CreateLink(defaultURL, CreateLinkOptions{
+		Alias: "night",
+		TimeRules: []models.TimeRule{{
+			AvailabilityWindow: models.AvailabilityWindow{
+				Days: []string{"fri"}, Start: "22:00", End: "02:00", Timezone: "America/New_York",
+			},
+			URL: ruleURL,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("CreateLink: %v", err)
+	}
+
+	tests := []struct {
+		at       string
+		wantRule int
+		wantNext string
+	}{
+		{"2026-10-17T03:30:00Z", 1, "2026-10-17T06:00:00Z"}, // Dans la plage : fin samedi 02:00 à New York
+		{"2026-10-17T06:00:00Z", 0, "2026-10-24T02:00:00Z"}, // Fin de la plage : réouverture le vendredi suivant
+		{"2026-10-16T12:34:56Z", 0, "2026-10-17T02:00:00Z"}, // Vendredi midi : ouverture le soir même
+	}
+	for _, tt := range tests {
+		_, route, err := service.ActiveDestination("night", utc(tt.at))
+		if err != nil {
+			t.Fatalf("ActiveDestination at %s: %v", tt.at, err)
+		}
+		if route.RuleIndex != tt.wantRule {
+			t.Errorf("rule at %s = %d, want %d", tt.at, route.RuleIndex, tt.wantRule)
+		}
+		if route.NextChange == nil || !route.NextChange.Equal(utc(tt.wantNext)) {
+			t.Errorf("next change after %s = %v, want %s", tt.at, route.NextChange, tt.wantNext)
+		}
+	}
+}
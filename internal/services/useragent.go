@@ -0,0 +1,110 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Systèmes d'exploitation reconnus dans un User-Agent.
+const (
+	OSiOS      = "ios"
+	OSAndroid  = "android"
+	OSWindows  = "windows"
+	OSMacOS    = "macos"
+	OSLinux    = "linux"
+	OSChromeOS = "chromeos"
+	OSOther    = "other"
+)
+
+// Classes d'appareils reconnues dans un User-Agent.
+const (
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+	DeviceBot     = "bot"
+)
+
+// Navigateurs reconnus dans un User-Agent.
+const (
+	BrowserChrome  = "chrome"
+	BrowserSafari  = "safari"
+	BrowserFirefox = "firefox"
+	BrowserEdge    = "edge"
+	BrowserOpera   = "opera"
+	BrowserSamsung = "samsung"
+	BrowserOther   = "other"
+)
+
+// botPattern reconnaît les robots d'indexation, les aperçus de liens et les clients HTTP en ligne de commande.
+var botPattern = regexp.MustCompile(`(?i)bot\b|crawler|spider|slurp|facebookexternalhit|embedly|preview|curl/|wget/|python-requests|go-http-client|okhttp|headless`)
+
+// UserAgent décrit l'appareil d'un visiteur, déduit de son en-tête User-Agent.
+type UserAgent struct {
+	OS      string `json:"os"`
+	Device  string `json:"device"`
+	Browser string `json:"browser"`
+}
+
+// ParseUserAgent analyse un en-tête User-Agent. L'analyse repose sur les jetons usuels des
+// navigateurs : elle ne cherche pas à identifier les modèles d'appareils. Un en-tête vide
+// ou inconnu donne un ordinateur de bureau sous un système et un navigateur "other".
+func ParseUserAgent(header string) UserAgent {
+	ua := UserAgent{OS: parseOS(header), Browser: parseBrowser(header)}
+
+	switch {
+	case botPattern.MatchString(header):
+		ua.Device = DeviceBot
+	case strings.Contains(header, "iPad") || strings.Contains(header, "Tablet"):
+		ua.Device = DeviceTablet
+	case ua.OS == OSAndroid && !strings.Contains(header, "Mobile"):
+		// Les tablettes Android n'annoncent pas le jeton "Mobile".
+		ua.Device = DeviceTablet
+	case strings.Contains(header, "Mobi") || strings.Contains(header, "iPhone") || strings.Contains(header, "iPod"):
+		ua.Device = DeviceMobile
+	default:
+		ua.Device = DeviceDesktop
+	}
+	return ua
+}
+
+// parseOS déduit le système d'exploitation d'un User-Agent. L'ordre des tests compte :
+// Android et ChromeOS annoncent aussi "Linux", iOS annonce aussi "Mac OS X".
+func parseOS(header string) string {
+	switch {
+	case strings.Contains(header, "iPhone") || strings.Contains(header, "iPad") || strings.Contains(header, "iPod"):
+		return OSiOS
+	case strings.Contains(header, "Android"):
+		return OSAndroid
+	case strings.Contains(header, "CrOS"):
+		return OSChromeOS
+	case strings.Contains(header, "Windows"):
+		return OSWindows
+	case strings.Contains(header, "Macintosh") || strings.Contains(header, "Mac OS X"):
+		return OSMacOS
+	case strings.Contains(header, "Linux") || strings.Contains(header, "X11"):
+		return OSLinux
+	default:
+		return OSOther
+	}
+}
+
+// parseBrowser déduit le navigateur d'un User-Agent. Les navigateurs basés sur Chromium annoncent
+// aussi "Chrome" et "Safari" : ils sont donc testés en premier.
+func parseBrowser(header string) string {
+	switch {
+	case strings.Contains(header, "Edg/") || strings.Contains(header, "EdgA/") || strings.Contains(header, "EdgiOS/") || strings.Contains(header, "Edge/"):
+		return BrowserEdge
+	case strings.Contains(header, "OPR/") || strings.Contains(header, "Opera"):
+		return BrowserOpera
+	case strings.Contains(header, "SamsungBrowser/"):
+		return BrowserSamsung
+	case strings.Contains(header, "Firefox/") || strings.Contains(header, "FxiOS/"):
+		return BrowserFirefox
+	case strings.Contains(header, "Chrome/") || strings.Contains(header, "CriOS/"):
+		return BrowserChrome
+	case strings.Contains(header, "Safari/"):
+		return BrowserSafari
+	default:
+		return BrowserOther
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"sort"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// Contraintes appliquées aux destinations multiples (tests A/B) d'un lien.
+const (
+	minVariants     = 2
+	maxVariants     = 10
+	maxVariantTotal = 10000 // Somme maximale des poids
+)
+
+// variantNamePattern définit les noms de variantes acceptés.
+var variantNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+// ErrInvalidVariants est renvoyée lorsque les destinations multiples d'un lien sont invalides.
+var ErrInvalidVariants = newDomainError(ErrInvalidInput, "invalid_variants", "variants are invalid")
+
+// prepareVariants valide les variantes d'un lien et met leurs URLs sous forme canonique.
+func (s *LinkService) prepareVariants(variants []models.LinkVariant) ([]models.LinkVariant, error) {
+	if len(variants) < minVariants || len(variants) > maxVariants {
+		return nil, fmt.Errorf("%w: a link needs between %d and %d variants", ErrInvalidVariants, minVariants, maxVariants)
+	}
+
+	prepared := make([]models.LinkVariant, 0, len(variants))
+	names := make(map[string]struct{}, len(variants))
+	total := 0
+	for _, v := range variants {
+		if !variantNamePattern.MatchString(v.Name) {
+			return nil, fmt.Errorf("%w: name '%s' must be 1 to 32 letters, digits, '-' or '_'", ErrInvalidVariants, v.Name)
+		}
+		if _, duplicate := names[v.Name]; duplicate {
+			return nil, fmt.Errorf("%w: duplicate name '%s'", ErrInvalidVariants, v.Name)
+		}
+		names[v.Name] = struct{}{}
+
+		if v.Weight <= 0 {
+			return nil, fmt.Errorf("%w: weight of '%s' must be greater than 0", ErrInvalidVariants, v.Name)
+		}
+		total += v.Weight
+		if total > maxVariantTotal {
+			return nil, fmt.Errorf("%w: total weight must not exceed %d", ErrInvalidVariants, maxVariantTotal)
+		}
+
+		destination, err := s.prepareDestination(v.URL)
+		if err != nil {
+			return nil, fmt.Errorf("variant '%s': %w", v.Name, err)
+		}
+		prepared = append(prepared, models.LinkVariant{Name: v.Name, URL: destination, Weight: v.Weight})
+	}
+	return prepared, nil
+}
+
+// pickVariant choisit la variante à servir pour une visite. Une variante déjà attribuée au visiteur
+// (sticky) est conservée si elle existe toujours ; sinon, le tirage est pondéré par les poids.
+func pickVariant(variants []models.LinkVariant, sticky string) *models.LinkVariant {
+	if len(variants) == 0 {
+		return nil
+	}
+	if sticky != "" {
+		for i := range variants {
+			if variants[i].Name == sticky {
+				return &variants[i]
+			}
+		}
+	}
+
+	total := 0
+	for _, v := range variants {
+		total += v.Weight
+	}
+	n := rand.IntN(total)
+	for i := range variants {
+		if n < variants[i].Weight {
+			return &variants[i]
+		}
+		n -= variants[i].Weight
+	}
+	return &variants[len(variants)-1]
+}
+
+// VariantStats décrit les clics servis par une variante d'un lien.
+type VariantStats struct {
+	Name   string  `json:"name"`
+	URL    string  `json:"url"`
+	Weight int     `json:"weight"`
+	Clicks int64   `json:"clicks"`
+	Share  float64 `json:"share"` // Part des clics du lien servis par cette variante, entre 0 et 1
+}
+
+// GetVariantStats répartit les clics d'un lien entre ses variantes, dans l'ordre de leur définition.
+// Les clics enregistrés pour une variante qui n'existe plus sont regroupés sous son nom d'origine, à la fin.
+func (s *LinkService) GetVariantStats(link *models.Link) ([]VariantStats, error) {
+	counts, err := s.linkRepo.CountClicksByVariant(link.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error counting clicks by variant for link ID %d: %w", link.ID, err)
+	}
+
+	var total int64
+	for name, count := range counts {
+		if name != "" {
+			total += count
+		}
+	}
+
+	stats := make([]VariantStats, 0, len(link.Variants))
+	for _, v := range link.Variants {
+		stats = append(stats, VariantStats{Name: v.Name, URL: v.URL, Weight: v.Weight, Clicks: counts[v.Name]})
+		delete(counts, v.Name)
+	}
+	removed := make([]string, 0, len(counts))
+	for name := range counts {
+		if name != "" {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		stats = append(stats, VariantStats{Name: name, Clicks: counts[name]})
+	}
+
+	if total > 0 {
+		for i := range stats {
+			stats[i].Share = float64(stats[i].Clicks) / float64(total)
+		}
+	}
+	return stats, nil
+}
@@ -27,6 +27,8 @@ func clickWorker(clickEventsChan <-chan models.ClickEvent, clickRepo repository.
 			Timestamp: event.Timestamp,
 			UserAgent: event.UserAgent,
 			IPAddress: event.IPAddress,
+			Variant:   event.Variant,
+			Language:  event.Language,
 		}
 
 
@@ -0,0 +1,36 @@
+package workers
+
+import (
+	"log"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// StartExpirationSweeper lance une goroutine qui marque périodiquement les liens expirés
+// (date dépassée ou budget de clics épuisé), afin qu'ils ne soient plus considérés comme actifs.
+func StartExpirationSweeper(interval time.Duration, linkRepo repository.LinkRepository) {
+	log.Printf("Starting expiration sweeper (interval: %v)...", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		// Premier passage immédiat pour traiter les liens expirés pendant l'arrêt du serveur.
+		sweepExpiredLinks(linkRepo)
+		for range ticker.C {
+			sweepExpiredLinks(linkRepo)
+		}
+	}()
+}
+
+// sweepExpiredLinks effectue un passage du balayeur.
+func sweepExpiredLinks(linkRepo repository.LinkRepository) {
+	count, err := linkRepo.MarkExpiredLinks(time.Now())
+	if err != nil {
+		log.Printf("ERROR: Expiration sweep failed: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("Expiration sweep: %d link(s) marked as expired", count)
+	}
+}
@@ -0,0 +1,86 @@
+package workers
+
+import (
+	"log"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/metadata"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// metadataBatchSize est le nombre maximal de liens remis en file à chaque passage du planificateur.
+const metadataBatchSize = 100
+
+// MetadataSchedule fixe le délai avant la prochaine récupération des informations d'un lien.
+type MetadataSchedule struct {
+	Refresh time.Duration // Après une récupération réussie
+	Retry   time.Duration // Après un échec
+}
+
+// StartMetadataWorkers lance un pool de goroutines qui récupèrent les informations des pages de
+// destination demandées sur le channel et les enregistrent en base.
+func StartMetadataWorkers(workerCount int, requests <-chan models.MetadataRequest, linkRepo repository.LinkRepository, fetcher *metadata.Fetcher, schedule MetadataSchedule) {
+	log.Printf("Starting %d metadata worker(s)...", workerCount)
+	for i := 0; i < workerCount; i++ {
+		go metadataWorker(requests, linkRepo, fetcher, schedule)
+	}
+}
+
+// metadataWorker traite les demandes de récupération une par une.
+func metadataWorker(requests <-chan models.MetadataRequest, linkRepo repository.LinkRepository, fetcher *metadata.Fetcher, schedule MetadataSchedule) {
+	for request := range requests {
+		now := time.Now()
+		fetched, err := fetcher.Fetch(request.URL)
+		if err != nil {
+			// Les informations déjà connues sont gardées : un échec passager ne les efface pas.
+			fetched = &models.PageMetadata{}
+			if request.Current != nil {
+				*fetched = *request.Current
+			}
+			fetched.Error = err.Error()
+		}
+		fetched.FetchedAt = now.UTC()
+
+		next := now.Add(schedule.Refresh)
+		if err != nil {
+			next = now.Add(schedule.Retry)
+			log.Printf("Metadata fetch failed for LinkID %d (%s), retrying at %s: %v", request.LinkID, request.URL, next.Format(time.RFC3339), err)
+		}
+		if err := linkRepo.UpdateMetadata(request.LinkID, fetched, next); err != nil {
+			log.Printf("ERROR: Failed to save metadata for LinkID %d: %v", request.LinkID, err)
+		}
+	}
+}
+
+// StartMetadataScheduler lance une goroutine qui remet périodiquement en file les liens dont les
+// informations sont à récupérer : ceux créés sans passer par le serveur (CLI), ceux dont la
+// récupération a échoué et ceux dont les informations sont à rafraîchir.
+func StartMetadataScheduler(interval time.Duration, linkRepo repository.LinkRepository, requests chan<- models.MetadataRequest) {
+	log.Printf("Starting metadata scheduler (interval: %v)...", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		scheduleMetadata(linkRepo, requests)
+		for range ticker.C {
+			scheduleMetadata(linkRepo, requests)
+		}
+	}()
+}
+
+// scheduleMetadata effectue un passage du planificateur. L'envoi est bloquant : le planificateur
+// avance au rythme des workers.
+func scheduleMetadata(linkRepo repository.LinkRepository, requests chan<- models.MetadataRequest) {
+	links, err := linkRepo.FindLinksDueForMetadata(time.Now(), metadataBatchSize)
+	if err != nil {
+		log.Printf("ERROR: Metadata scheduling failed: %v", err)
+		return
+	}
+	for _, link := range links {
+		requests <- models.MetadataRequest{LinkID: link.ID, URL: link.LongURL, Current: link.Metadata}
+	}
+	if len(links) > 0 {
+		log.Printf("Metadata scheduler: %d link(s) queued", len(links))
+	}
+}